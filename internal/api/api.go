@@ -0,0 +1,115 @@
+// Package api implements the public HTTP API, handling listing, metadata and
+// redirecting image requests to the image processing service.
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/DMarby/picsum-photos/internal/database"
+	"github.com/DMarby/picsum-photos/internal/faces"
+	"github.com/DMarby/picsum-photos/internal/fingerprint"
+	"github.com/DMarby/picsum-photos/internal/format"
+	"github.com/DMarby/picsum-photos/internal/hmac"
+	"github.com/DMarby/picsum-photos/internal/metadata"
+	"github.com/DMarby/picsum-photos/internal/tracing"
+)
+
+// Valid values for the ?crop= query parameter.
+var validCropModes = map[string]bool{"center": true, "face": true, "entropy": true, "smart": true}
+
+// Maximum allowed width/height for an image request.
+const maxImageSize = 5000
+
+// Default/min/max blur amount.
+const (
+	defaultBlur = 5
+	minBlur     = 1
+	maxBlur     = 10
+)
+
+// Default/max pagination limit.
+const (
+	defaultLimit = 30
+	maxLimit     = 100
+)
+
+// ListImage represents an image entry returned by the listing/info endpoints.
+type ListImage struct {
+	database.Image
+	DownloadURL string         `json:"download_url"`
+	Exif        *metadata.EXIF `json:"exif,omitempty"`
+}
+
+// DeprecatedImage represents an image entry as returned by the deprecated /list endpoint.
+type DeprecatedImage struct {
+	Format    string `json:"format"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	Filename  string `json:"filename"`
+	ID        int    `json:"id"`
+	Author    string `json:"author"`
+	AuthorURL string `json:"author_url"`
+	PostURL   string `json:"post_url"`
+}
+
+// API holds the dependencies and configuration used by the HTTP handlers.
+type API struct {
+	Database        database.Provider
+	Log             *zap.Logger
+	Tracer          *tracing.Tracer
+	RootURL         string
+	ImageServiceURL string
+	CacheDuration   time.Duration
+	HMAC            *hmac.HMAC
+	Metadata        *metadata.Service
+	Faces           *faces.Service
+	Fingerprint     *fingerprint.Service
+	Formats         *format.Registry
+
+	// SignedURLTTL, when non-zero, is embedded as a signed exp=<unix-seconds>
+	// parameter on every redirect this service issues, so the image-processing
+	// service can reject it once expired. A zero value signs redirects without
+	// an expiry, as before.
+	SignedURLTTL time.Duration
+
+	// TokenMintKey gates /v2/token: a request must present it via the
+	// X-Token-Mint-Key header to mint a bearer token. A nil/empty key
+	// disables the endpoint, since minting is an operator action.
+	TokenMintKey []byte
+}
+
+// Router builds the HTTP router for the API.
+func (a *API) Router() (http.Handler, error) {
+	r := mux.NewRouter().StrictSlash(true)
+	r.NotFoundHandler = http.HandlerFunc(notFoundHandler)
+
+	r.HandleFunc("/v2/token", a.tokenHandler).Methods(http.MethodGet)
+	r.HandleFunc("/v2/list", a.withAuth(a.v2ListHandler)).Methods(http.MethodGet)
+	r.HandleFunc("/list", a.deprecatedListHandler).Methods(http.MethodGet)
+
+	r.HandleFunc("/id/{id}/info", a.withAuth(a.infoHandler)).Methods(http.MethodGet)
+	r.HandleFunc("/seed/{seed}/info", a.withAuth(a.infoHandler)).Methods(http.MethodGet)
+
+	r.HandleFunc("/id/{id}/exif", a.exifHandler).Methods(http.MethodGet)
+	r.HandleFunc("/seed/{seed}/exif", a.exifHandler).Methods(http.MethodGet)
+
+	r.HandleFunc("/id/{id}/faces", a.facesHandler).Methods(http.MethodGet)
+	r.HandleFunc("/seed/{seed}/faces", a.facesHandler).Methods(http.MethodGet)
+
+	a.registerImageRoutes(r)
+
+	return r, nil
+}
+
+func notFoundHandler(w http.ResponseWriter, r *http.Request) {
+	writeNoCacheHeaders(w)
+	http.Error(w, "page not found", http.StatusNotFound)
+}
+
+func writeNoCacheHeaders(w http.ResponseWriter) {
+	w.Header().Set("Cache-Control", "private, no-cache, no-store, must-revalidate")
+}
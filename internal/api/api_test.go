@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"image"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"reflect"
 	"strings"
@@ -13,18 +15,53 @@ import (
 
 	"github.com/DMarby/picsum-photos/internal/api"
 	"github.com/DMarby/picsum-photos/internal/database"
+	"github.com/DMarby/picsum-photos/internal/faces"
+	"github.com/DMarby/picsum-photos/internal/fingerprint"
+	"github.com/DMarby/picsum-photos/internal/format"
 	"github.com/DMarby/picsum-photos/internal/hmac"
 	"github.com/DMarby/picsum-photos/internal/logger"
+	"github.com/DMarby/picsum-photos/internal/metadata"
+	"github.com/DMarby/picsum-photos/internal/signer"
+	"github.com/DMarby/picsum-photos/internal/storage/limit"
+	"github.com/DMarby/picsum-photos/internal/token"
 	"github.com/DMarby/picsum-photos/internal/tracing"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 
 	fileDatabase "github.com/DMarby/picsum-photos/internal/database/file"
 	mockDatabase "github.com/DMarby/picsum-photos/internal/database/mock"
+	mockStorage "github.com/DMarby/picsum-photos/internal/storage/mock"
 
 	"testing"
 )
 
+// exifFixture is a minimal JPEG with a single-entry EXIF IFD0 (Orientation = 1).
+var exifFixture = []byte{
+	0xFF, 0xD8, // SOI
+	0xFF, 0xE1, 0x00, 0x22, // APP1, length 34
+	0x45, 0x78, 0x69, 0x66, 0x00, 0x00, // "Exif\0\0"
+	0x49, 0x49, 0x2A, 0x00, 0x08, 0x00, 0x00, 0x00, // TIFF header, IFD0 @ offset 8
+	0x01, 0x00, // 1 entry
+	0x12, 0x01, 0x03, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, // Orientation = 1
+	0x00, 0x00, 0x00, 0x00, // next IFD offset
+	0xFF, 0xD9, // EOI
+}
+
+// noExifFixture is a minimal JPEG with no EXIF segment.
+var noExifFixture = []byte{0xFF, 0xD8, 0xFF, 0xD9}
+
+// fakeDetector is a faces.Detector stub that detects a single face in 8x8 images,
+// to avoid depending on the real Pigo cascade classifier in tests.
+type fakeDetector struct{}
+
+func (fakeDetector) Detect(img image.Image) []faces.Face {
+	if img.Bounds().Dx() != 8 {
+		return nil
+	}
+
+	return []faces.Face{{X: 1, Y: 1, W: 4, H: 4, Score: 10}}
+}
+
 const rootURL = "https://example.com"
 const imageServiceURL = "https://i.example.com"
 
@@ -34,6 +71,7 @@ func TestAPI(t *testing.T) {
 
 	db, _ := fileDatabase.New("../../test/fixtures/file/metadata.json")
 	dbMultiple, _ := fileDatabase.New("../../test/fixtures/file/metadata_multiple.json")
+	dbFilters, _ := fileDatabase.New("../../test/fixtures/file/metadata_filters.json")
 
 	hmac := &hmac.HMAC{
 		Key: []byte("test"),
@@ -49,9 +87,37 @@ func TestAPI(t *testing.T) {
 		},
 	}
 
-	router, _ := (&api.API{db, log, tracer, rootURL, imageServiceURL, time.Minute, hmac}).Router()
-	paginationRouter, _ := (&api.API{dbMultiple, log, tracer, rootURL, imageServiceURL, time.Minute, hmac}).Router()
-	mockDatabaseRouter, _ := (&api.API{&mockDatabase.Provider{}, log, tracer, rootURL, imageServiceURL, time.Minute, hmac}).Router()
+	metadataService := metadata.New(&mockStorage.Provider{
+		Data: map[string][]byte{
+			"1.jpg": exifFixture,
+			"2.jpg": noExifFixture,
+		},
+	})
+
+	facesService := faces.New(&mockStorage.Provider{
+		Data: map[string][]byte{
+			"1.jpg": readFile("../../test/fixtures/file/face.jpg"),
+			"2.jpg": readFile("../../test/fixtures/file/noface.jpg"),
+		},
+	}, fakeDetector{}, faces.DefaultThresholds)
+
+	oversizeMetadataService := metadata.New(limit.New(&mockStorage.Provider{
+		Data: map[string][]byte{"1.jpg": exifFixture},
+	}, 1))
+
+	oversizeFacesService := faces.New(limit.New(&mockStorage.Provider{
+		Data: map[string][]byte{"1.jpg": readFile("../../test/fixtures/file/face.jpg")},
+	}, 1), fakeDetector{}, faces.DefaultThresholds)
+
+	validToken, _ := token.Issue(hmac, token.Claims{}, time.Minute)
+	expiredToken, _ := token.Issue(hmac, token.Claims{}, -time.Minute)
+	tamperedToken := validToken[:len(validToken)-1] + "0"
+
+	router, _ := (&api.API{db, log, tracer, rootURL, imageServiceURL, time.Minute, hmac, metadataService, facesService, fingerprint.New(), format.New(), 0, nil}).Router()
+	paginationRouter, _ := (&api.API{dbMultiple, log, tracer, rootURL, imageServiceURL, time.Minute, hmac, metadataService, facesService, fingerprint.New(), format.New(), 0, nil}).Router()
+	mockDatabaseRouter, _ := (&api.API{&mockDatabase.Provider{}, log, tracer, rootURL, imageServiceURL, time.Minute, hmac, metadataService, facesService, fingerprint.New(), format.New(), 0, nil}).Router()
+	oversizeRouter, _ := (&api.API{dbMultiple, log, tracer, rootURL, imageServiceURL, time.Minute, hmac, oversizeMetadataService, oversizeFacesService, fingerprint.New(), format.New(), 0, nil}).Router()
+	filtersRouter, _ := (&api.API{dbFilters, log, tracer, rootURL, imageServiceURL, time.Minute, hmac, metadataService, facesService, fingerprint.New(), format.New(), 0, nil}).Router()
 
 	tests := []struct {
 		Name             string
@@ -60,6 +126,7 @@ func TestAPI(t *testing.T) {
 		ExpectedStatus   int
 		ExpectedResponse []byte
 		ExpectedHeaders  map[string]string
+		Authorization    string
 	}{
 		{
 			Name:           "/v2/list lists images",
@@ -90,7 +157,7 @@ func TestAPI(t *testing.T) {
 			}),
 			ExpectedHeaders: map[string]string{
 				"Content-Type":                  "application/json",
-				"Link":                          fmt.Sprintf("<%s/v2/list?page=2&limit=30>; rel=\"next\"", rootURL),
+				"Link":                          fmt.Sprintf("<%s/v2/list?limit=30&page=2>; rel=\"next\"", rootURL),
 				"Cache-Control":                 "private, no-cache, no-store, must-revalidate",
 				"Access-Control-Expose-Headers": "Link",
 			},
@@ -124,10 +191,362 @@ func TestAPI(t *testing.T) {
 			}),
 			ExpectedHeaders: map[string]string{
 				"Content-Type":  "application/json",
-				"Link":          fmt.Sprintf("<%s/v2/list?page=2&limit=100>; rel=\"next\"", rootURL),
+				"Link":          fmt.Sprintf("<%s/v2/list?limit=100&page=2>; rel=\"next\"", rootURL),
+				"Cache-Control": "private, no-cache, no-store, must-revalidate",
+			},
+		},
+		{
+			Name:           "/v2/list filters by orientation",
+			URL:            "/v2/list?orientation=portrait",
+			Router:         paginationRouter,
+			ExpectedStatus: http.StatusOK,
+			ExpectedResponse: marshalJson([]api.ListImage{
+				{
+					Image: database.Image{
+						ID:     "1",
+						Author: "John Doe",
+						URL:    "https://picsum.photos",
+						Width:  300,
+						Height: 400,
+					},
+					DownloadURL: fmt.Sprintf("%s/id/1/300/400", rootURL),
+				},
+				{
+					Image: database.Image{
+						ID:     "2",
+						Author: "John Doe",
+						URL:    "https://picsum.photos",
+						Width:  300,
+						Height: 400,
+					},
+					DownloadURL: fmt.Sprintf("%s/id/2/300/400", rootURL),
+				},
+			}),
+			ExpectedHeaders: map[string]string{
+				"Content-Type":  "application/json",
+				"Cache-Control": "private, no-cache, no-store, must-revalidate",
+			},
+		},
+		{
+			Name:             "/v2/list filters by landscape orientation finds nothing",
+			URL:              "/v2/list?orientation=landscape",
+			Router:           paginationRouter,
+			ExpectedStatus:   http.StatusOK,
+			ExpectedResponse: marshalJson([]api.ListImage{}),
+			ExpectedHeaders: map[string]string{
+				"Content-Type":  "application/json",
+				"Cache-Control": "private, no-cache, no-store, must-revalidate",
+			},
+		},
+		{
+			Name:           "/v2/list sorts by height descending",
+			URL:            "/v2/list?sort=height&order=desc&limit=1",
+			Router:         paginationRouter,
+			ExpectedStatus: http.StatusOK,
+			ExpectedResponse: marshalJson([]api.ListImage{
+				{
+					Image: database.Image{
+						ID:     "1",
+						Author: "John Doe",
+						URL:    "https://picsum.photos",
+						Width:  300,
+						Height: 400,
+					},
+					DownloadURL: fmt.Sprintf("%s/id/1/300/400", rootURL),
+				},
+			}),
+			ExpectedHeaders: map[string]string{
+				"Content-Type":  "application/json",
+				"Link":          fmt.Sprintf("<%s/v2/list?limit=1&order=desc&page=2&sort=height>; rel=\"next\"", rootURL),
+				"Cache-Control": "private, no-cache, no-store, must-revalidate",
+			},
+		},
+		{
+			Name:           "/v2/list sorts by width ascending",
+			URL:            "/v2/list?sort=width",
+			Router:         filtersRouter,
+			ExpectedStatus: http.StatusOK,
+			ExpectedResponse: marshalJson([]api.ListImage{
+				{
+					Image:       database.Image{ID: "2", Author: "Bob", URL: "https://picsum.photos", Width: 400, Height: 300},
+					DownloadURL: fmt.Sprintf("%s/id/2/400/300", rootURL),
+				},
+				{
+					Image:       database.Image{ID: "1", Author: "Alice", URL: "https://picsum.photos", Width: 800, Height: 600},
+					DownloadURL: fmt.Sprintf("%s/id/1/800/600", rootURL),
+				},
+				{
+					Image:       database.Image{ID: "3", Author: "Alice", URL: "https://picsum.photos", Width: 1600, Height: 900},
+					DownloadURL: fmt.Sprintf("%s/id/3/1600/900", rootURL),
+				},
+			}),
+			ExpectedHeaders: map[string]string{
+				"Content-Type":  "application/json",
+				"Cache-Control": "private, no-cache, no-store, must-revalidate",
+			},
+		},
+		{
+			Name:           "/v2/list sorts by author ascending",
+			URL:            "/v2/list?sort=author",
+			Router:         filtersRouter,
+			ExpectedStatus: http.StatusOK,
+			ExpectedResponse: marshalJson([]api.ListImage{
+				{
+					Image:       database.Image{ID: "1", Author: "Alice", URL: "https://picsum.photos", Width: 800, Height: 600},
+					DownloadURL: fmt.Sprintf("%s/id/1/800/600", rootURL),
+				},
+				{
+					Image:       database.Image{ID: "3", Author: "Alice", URL: "https://picsum.photos", Width: 1600, Height: 900},
+					DownloadURL: fmt.Sprintf("%s/id/3/1600/900", rootURL),
+				},
+				{
+					Image:       database.Image{ID: "2", Author: "Bob", URL: "https://picsum.photos", Width: 400, Height: 300},
+					DownloadURL: fmt.Sprintf("%s/id/2/400/300", rootURL),
+				},
+			}),
+			ExpectedHeaders: map[string]string{
+				"Content-Type":  "application/json",
+				"Cache-Control": "private, no-cache, no-store, must-revalidate",
+			},
+		},
+		{
+			Name:           "/v2/list filters by author",
+			URL:            "/v2/list?author=Alice",
+			Router:         filtersRouter,
+			ExpectedStatus: http.StatusOK,
+			ExpectedResponse: marshalJson([]api.ListImage{
+				{
+					Image:       database.Image{ID: "1", Author: "Alice", URL: "https://picsum.photos", Width: 800, Height: 600},
+					DownloadURL: fmt.Sprintf("%s/id/1/800/600", rootURL),
+				},
+				{
+					Image:       database.Image{ID: "3", Author: "Alice", URL: "https://picsum.photos", Width: 1600, Height: 900},
+					DownloadURL: fmt.Sprintf("%s/id/3/1600/900", rootURL),
+				},
+			}),
+			ExpectedHeaders: map[string]string{
+				"Content-Type":  "application/json",
+				"Cache-Control": "private, no-cache, no-store, must-revalidate",
+			},
+		},
+		{
+			Name:             "/v2/list filters by a non-matching author finds nothing",
+			URL:              "/v2/list?author=Carol",
+			Router:           filtersRouter,
+			ExpectedStatus:   http.StatusOK,
+			ExpectedResponse: marshalJson([]api.ListImage{}),
+			ExpectedHeaders: map[string]string{
+				"Content-Type":  "application/json",
+				"Cache-Control": "private, no-cache, no-store, must-revalidate",
+			},
+		},
+		{
+			Name:           "/v2/list filters by min_width",
+			URL:            "/v2/list?min_width=500",
+			Router:         filtersRouter,
+			ExpectedStatus: http.StatusOK,
+			ExpectedResponse: marshalJson([]api.ListImage{
+				{
+					Image:       database.Image{ID: "1", Author: "Alice", URL: "https://picsum.photos", Width: 800, Height: 600},
+					DownloadURL: fmt.Sprintf("%s/id/1/800/600", rootURL),
+				},
+				{
+					Image:       database.Image{ID: "3", Author: "Alice", URL: "https://picsum.photos", Width: 1600, Height: 900},
+					DownloadURL: fmt.Sprintf("%s/id/3/1600/900", rootURL),
+				},
+			}),
+			ExpectedHeaders: map[string]string{
+				"Content-Type":  "application/json",
+				"Cache-Control": "private, no-cache, no-store, must-revalidate",
+			},
+		},
+		{
+			Name:           "/v2/list filters by max_width",
+			URL:            "/v2/list?max_width=1000",
+			Router:         filtersRouter,
+			ExpectedStatus: http.StatusOK,
+			ExpectedResponse: marshalJson([]api.ListImage{
+				{
+					Image:       database.Image{ID: "1", Author: "Alice", URL: "https://picsum.photos", Width: 800, Height: 600},
+					DownloadURL: fmt.Sprintf("%s/id/1/800/600", rootURL),
+				},
+				{
+					Image:       database.Image{ID: "2", Author: "Bob", URL: "https://picsum.photos", Width: 400, Height: 300},
+					DownloadURL: fmt.Sprintf("%s/id/2/400/300", rootURL),
+				},
+			}),
+			ExpectedHeaders: map[string]string{
+				"Content-Type":  "application/json",
+				"Cache-Control": "private, no-cache, no-store, must-revalidate",
+			},
+		},
+		{
+			Name:           "/v2/list filters by min_height",
+			URL:            "/v2/list?min_height=400",
+			Router:         filtersRouter,
+			ExpectedStatus: http.StatusOK,
+			ExpectedResponse: marshalJson([]api.ListImage{
+				{
+					Image:       database.Image{ID: "1", Author: "Alice", URL: "https://picsum.photos", Width: 800, Height: 600},
+					DownloadURL: fmt.Sprintf("%s/id/1/800/600", rootURL),
+				},
+				{
+					Image:       database.Image{ID: "3", Author: "Alice", URL: "https://picsum.photos", Width: 1600, Height: 900},
+					DownloadURL: fmt.Sprintf("%s/id/3/1600/900", rootURL),
+				},
+			}),
+			ExpectedHeaders: map[string]string{
+				"Content-Type":  "application/json",
+				"Cache-Control": "private, no-cache, no-store, must-revalidate",
+			},
+		},
+		{
+			Name:           "/v2/list filters by max_height",
+			URL:            "/v2/list?max_height=700",
+			Router:         filtersRouter,
+			ExpectedStatus: http.StatusOK,
+			ExpectedResponse: marshalJson([]api.ListImage{
+				{
+					Image:       database.Image{ID: "1", Author: "Alice", URL: "https://picsum.photos", Width: 800, Height: 600},
+					DownloadURL: fmt.Sprintf("%s/id/1/800/600", rootURL),
+				},
+				{
+					Image:       database.Image{ID: "2", Author: "Bob", URL: "https://picsum.photos", Width: 400, Height: 300},
+					DownloadURL: fmt.Sprintf("%s/id/2/400/300", rootURL),
+				},
+			}),
+			ExpectedHeaders: map[string]string{
+				"Content-Type":  "application/json",
+				"Cache-Control": "private, no-cache, no-store, must-revalidate",
+			},
+		},
+		{
+			Name:             "/v2/list invalid negative min_width",
+			URL:              "/v2/list?min_width=-1",
+			Router:           filtersRouter,
+			ExpectedStatus:   http.StatusBadRequest,
+			ExpectedResponse: []byte("Invalid min_width\n"),
+			ExpectedHeaders: map[string]string{
+				"Content-Type":  "text/plain; charset=utf-8",
+				"Cache-Control": "private, no-cache, no-store, must-revalidate",
+			},
+		},
+		{
+			Name:             "/v2/list invalid negative max_height",
+			URL:              "/v2/list?max_height=-1",
+			Router:           filtersRouter,
+			ExpectedStatus:   http.StatusBadRequest,
+			ExpectedResponse: []byte("Invalid max_height\n"),
+			ExpectedHeaders: map[string]string{
+				"Content-Type":  "text/plain; charset=utf-8",
+				"Cache-Control": "private, no-cache, no-store, must-revalidate",
+			},
+		},
+		{
+			Name:             "/v2/list invalid orientation",
+			URL:              "/v2/list?orientation=upside-down",
+			Router:           paginationRouter,
+			ExpectedStatus:   http.StatusBadRequest,
+			ExpectedResponse: []byte("Invalid orientation\n"),
+			ExpectedHeaders: map[string]string{
+				"Content-Type":  "text/plain; charset=utf-8",
+				"Cache-Control": "private, no-cache, no-store, must-revalidate",
+			},
+		},
+		{
+			Name:           "/v2/list with a valid bearer token succeeds",
+			URL:            "/v2/list?limit=1000",
+			Router:         paginationRouter,
+			Authorization:  "Bearer " + validToken,
+			ExpectedStatus: http.StatusOK,
+			ExpectedResponse: marshalJson([]api.ListImage{
+				{
+					Image: database.Image{
+						ID:     "1",
+						Author: "John Doe",
+						URL:    "https://picsum.photos",
+						Width:  300,
+						Height: 400,
+					},
+					DownloadURL: fmt.Sprintf("%s/id/1/300/400", rootURL),
+				},
+				{
+					Image: database.Image{
+						ID:     "2",
+						Author: "John Doe",
+						URL:    "https://picsum.photos",
+						Width:  300,
+						Height: 400,
+					},
+					DownloadURL: fmt.Sprintf("%s/id/2/300/400", rootURL),
+				},
+			}),
+			ExpectedHeaders: map[string]string{
+				"Content-Type":  "application/json",
+				"Link":          fmt.Sprintf("<%s/v2/list?limit=100&page=2>; rel=\"next\"", rootURL),
+				"Cache-Control": "private, no-cache, no-store, must-revalidate",
+			},
+		},
+		{
+			Name:             "/v2/list with an expired bearer token",
+			URL:              "/v2/list",
+			Router:           paginationRouter,
+			Authorization:    "Bearer " + expiredToken,
+			ExpectedStatus:   http.StatusUnauthorized,
+			ExpectedResponse: []byte("Invalid bearer token\n"),
+			ExpectedHeaders: map[string]string{
+				"Content-Type":  "text/plain; charset=utf-8",
 				"Cache-Control": "private, no-cache, no-store, must-revalidate",
 			},
 		},
+		{
+			Name:             "/v2/list with a tampered bearer token",
+			URL:              "/v2/list",
+			Router:           paginationRouter,
+			Authorization:    "Bearer " + tamperedToken,
+			ExpectedStatus:   http.StatusUnauthorized,
+			ExpectedResponse: []byte("Invalid bearer token\n"),
+			ExpectedHeaders: map[string]string{
+				"Content-Type":  "text/plain; charset=utf-8",
+				"Cache-Control": "private, no-cache, no-store, must-revalidate",
+			},
+		},
+		{
+			Name:             "/v2/list with a malformed authorization header",
+			URL:              "/v2/list",
+			Router:           paginationRouter,
+			Authorization:    validToken,
+			ExpectedStatus:   http.StatusUnauthorized,
+			ExpectedResponse: []byte("Invalid authorization header\n"),
+			ExpectedHeaders: map[string]string{
+				"Content-Type":  "text/plain; charset=utf-8",
+				"Cache-Control": "private, no-cache, no-store, must-revalidate",
+			},
+		},
+		{
+			Name:           "/v2/list encodes special characters in the Link header",
+			URL:            "/v2/list?author=John+Doe&limit=1",
+			Router:         paginationRouter,
+			ExpectedStatus: http.StatusOK,
+			ExpectedResponse: marshalJson([]api.ListImage{
+				{
+					Image: database.Image{
+						ID:     "1",
+						Author: "John Doe",
+						URL:    "https://picsum.photos",
+						Width:  300,
+						Height: 400,
+					},
+					DownloadURL: fmt.Sprintf("%s/id/1/300/400", rootURL),
+				},
+			}),
+			ExpectedHeaders: map[string]string{
+				"Content-Type":                  "application/json",
+				"Link":                          fmt.Sprintf("<%s/v2/list?author=John+Doe&limit=1&page=2>; rel=\"next\"", rootURL),
+				"Cache-Control":                 "private, no-cache, no-store, must-revalidate",
+				"Access-Control-Expose-Headers": "Link",
+			},
+		},
 		{
 			Name:           "/v2/list pagination page 1",
 			URL:            "/v2/list?page=1&limit=1",
@@ -147,7 +566,7 @@ func TestAPI(t *testing.T) {
 			}),
 			ExpectedHeaders: map[string]string{
 				"Content-Type":                  "application/json",
-				"Link":                          fmt.Sprintf("<%s/v2/list?page=2&limit=1>; rel=\"next\"", rootURL),
+				"Link":                          fmt.Sprintf("<%s/v2/list?limit=1&page=2>; rel=\"next\"", rootURL),
 				"Cache-Control":                 "private, no-cache, no-store, must-revalidate",
 				"Access-Control-Expose-Headers": "Link",
 			},
@@ -171,7 +590,7 @@ func TestAPI(t *testing.T) {
 			}),
 			ExpectedHeaders: map[string]string{
 				"Content-Type":                  "application/json",
-				"Link":                          fmt.Sprintf("<%s/v2/list?page=1&limit=1>; rel=\"prev\", <%s/v2/list?page=3&limit=1>; rel=\"next\"", rootURL, rootURL),
+				"Link":                          fmt.Sprintf("<%s/v2/list?limit=1&page=1>; rel=\"prev\", <%s/v2/list?limit=1&page=3>; rel=\"next\"", rootURL, rootURL),
 				"Cache-Control":                 "private, no-cache, no-store, must-revalidate",
 				"Access-Control-Expose-Headers": "Link",
 			},
@@ -184,7 +603,7 @@ func TestAPI(t *testing.T) {
 			ExpectedResponse: marshalJson([]api.ListImage{}),
 			ExpectedHeaders: map[string]string{
 				"Content-Type":                  "application/json",
-				"Link":                          fmt.Sprintf("<%s/v2/list?page=2&limit=1>; rel=\"prev\"", rootURL),
+				"Link":                          fmt.Sprintf("<%s/v2/list?limit=1&page=2>; rel=\"prev\"", rootURL),
 				"Cache-Control":                 "private, no-cache, no-store, must-revalidate",
 				"Access-Control-Expose-Headers": "Link",
 			},
@@ -256,35 +675,130 @@ func TestAPI(t *testing.T) {
 			},
 		},
 
+		{
+			Name:           "/id/{id}/info?include=exif inlines exif data",
+			URL:            "/id/1/info?include=exif",
+			Router:         paginationRouter,
+			ExpectedStatus: http.StatusOK,
+			ExpectedResponse: marshalJson(
+				api.ListImage{
+					Image: database.Image{
+						ID:     "1",
+						Author: "John Doe",
+						URL:    "https://picsum.photos",
+						Width:  300,
+						Height: 400,
+					},
+					DownloadURL: fmt.Sprintf("%s/id/1/300/400", rootURL),
+					Exif:        &metadata.EXIF{Orientation: 1},
+				},
+			),
+			ExpectedHeaders: map[string]string{
+				"Content-Type":  "application/json",
+				"Cache-Control": "private, no-cache, no-store, must-revalidate",
+			},
+		},
+		{
+			Name:             "/id/{id}/exif returns parsed exif data",
+			URL:              "/id/1/exif",
+			Router:           paginationRouter,
+			ExpectedStatus:   http.StatusOK,
+			ExpectedResponse: marshalJson(&metadata.EXIF{Orientation: 1}),
+			ExpectedHeaders: map[string]string{
+				"Content-Type":  "application/json",
+				"Cache-Control": "private, no-cache, no-store, must-revalidate",
+			},
+		},
+		{
+			Name:             "/id/{id}/exif 404s for an image with no exif data",
+			URL:              "/id/2/exif",
+			Router:           paginationRouter,
+			ExpectedStatus:   http.StatusNotFound,
+			ExpectedResponse: []byte("No EXIF data\n"),
+			ExpectedHeaders: map[string]string{
+				"Content-Type":  "text/plain; charset=utf-8",
+				"Cache-Control": "private, no-cache, no-store, must-revalidate",
+			},
+		},
+
+		{
+			Name:             "/id/{id}/faces returns detected faces",
+			URL:              "/id/1/faces",
+			Router:           paginationRouter,
+			ExpectedStatus:   http.StatusOK,
+			ExpectedResponse: marshalJson([]faces.Face{{X: 1, Y: 1, W: 4, H: 4, Score: 10}}),
+			ExpectedHeaders: map[string]string{
+				"Content-Type":  "application/json",
+				"Cache-Control": "private, no-cache, no-store, must-revalidate",
+			},
+		},
+		{
+			Name:             "/id/{id}/faces returns an empty list when no faces are detected",
+			URL:              "/id/2/faces",
+			Router:           paginationRouter,
+			ExpectedStatus:   http.StatusOK,
+			ExpectedResponse: marshalJson([]faces.Face{}),
+			ExpectedHeaders: map[string]string{
+				"Content-Type":  "application/json",
+				"Cache-Control": "private, no-cache, no-store, must-revalidate",
+			},
+		},
+		{
+			Name:             "/id/{id}/exif 413s for an oversize asset",
+			URL:              "/id/1/exif",
+			Router:           oversizeRouter,
+			ExpectedStatus:   http.StatusRequestEntityTooLarge,
+			ExpectedResponse: []byte("Image exceeds the maximum allowed size\n"),
+			ExpectedHeaders: map[string]string{
+				"Content-Type":  "text/plain; charset=utf-8",
+				"Cache-Control": "private, no-cache, no-store, must-revalidate",
+			},
+		},
+		{
+			Name:             "/id/{id}/faces 413s for an oversize asset",
+			URL:              "/id/1/faces",
+			Router:           oversizeRouter,
+			ExpectedStatus:   http.StatusRequestEntityTooLarge,
+			ExpectedResponse: []byte("Image exceeds the maximum allowed size\n"),
+			ExpectedHeaders: map[string]string{
+				"Content-Type":  "text/plain; charset=utf-8",
+				"Cache-Control": "private, no-cache, no-store, must-revalidate",
+			},
+		},
+
 		// Errors
-		{"invalid image id", "/id/nonexistant/200/300", router, http.StatusNotFound, []byte("Image does not exist\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}},
-		{"invalid image id", "/id/nonexistant/info", router, http.StatusNotFound, []byte("Image does not exist\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}},
-		{"invalid size", "/id/1/1/9223372036854775808", router, http.StatusBadRequest, []byte("Invalid size\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}},   // Number larger then max int size to fail int parsing
-		{"invalid size", "/id/1/9223372036854775808/1", router, http.StatusBadRequest, []byte("Invalid size\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}},   // Number larger then max int size to fail int parsing
-		{"invalid size", "/id/1/5500/1", router, http.StatusBadRequest, []byte("Invalid size\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}},                  // Number larger then maxImageSize to fail int parsing
-		{"invalid size", "/seed/1/9223372036854775808/1", router, http.StatusBadRequest, []byte("Invalid size\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}}, // Number larger then maxImageSize to fail int parsing
-		{"invalid size", "/9223372036854775808", router, http.StatusBadRequest, []byte("Invalid size\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}},          // Number larger then maxImageSize to fail int parsing
-		{"invalid blur amount", "/id/1/100/100?blur=11", router, http.StatusBadRequest, []byte("Invalid blur amount\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}},
-		{"invalid blur amount", "/id/1/100/100?blur=0", router, http.StatusBadRequest, []byte("Invalid blur amount\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}},
-		{"invalid file extension", "/id/1/100/100.png", router, http.StatusBadRequest, []byte("Invalid file extension\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}},
+		{"invalid image id", "/id/nonexistant/200/300", router, http.StatusNotFound, []byte("Image does not exist\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}, ""},
+		{"invalid image id", "/id/nonexistant/info", router, http.StatusNotFound, []byte("Image does not exist\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}, ""},
+		{"invalid size", "/id/1/1/9223372036854775808", router, http.StatusBadRequest, []byte("Invalid size\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}, ""},   // Number larger then max int size to fail int parsing
+		{"invalid size", "/id/1/9223372036854775808/1", router, http.StatusBadRequest, []byte("Invalid size\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}, ""},   // Number larger then max int size to fail int parsing
+		{"invalid size", "/id/1/5500/1", router, http.StatusBadRequest, []byte("Invalid size\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}, ""},                  // Number larger then maxImageSize to fail int parsing
+		{"invalid size", "/seed/1/9223372036854775808/1", router, http.StatusBadRequest, []byte("Invalid size\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}, ""}, // Number larger then maxImageSize to fail int parsing
+		{"invalid size", "/9223372036854775808", router, http.StatusBadRequest, []byte("Invalid size\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}, ""},          // Number larger then maxImageSize to fail int parsing
+		{"invalid blur amount", "/id/1/100/100?blur=11", router, http.StatusBadRequest, []byte("Invalid blur amount\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}, ""},
+		{"invalid blur amount", "/id/1/100/100?blur=0", router, http.StatusBadRequest, []byte("Invalid blur amount\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}, ""},
+		{"invalid file extension", "/id/1/100/100.png", router, http.StatusBadRequest, []byte("Invalid file extension\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}, ""},
+		{"invalid crop mode", "/id/1/100/100?crop=blurry", router, http.StatusBadRequest, []byte("Invalid crop mode\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}, ""},
 		// Deprecated handler errors
-		{"invalid size", "/g/9223372036854775808", router, http.StatusBadRequest, []byte("Invalid size\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}}, // Number larger then max int size to fail int parsing
+		{"invalid size", "/g/9223372036854775808", router, http.StatusBadRequest, []byte("Invalid size\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}, ""}, // Number larger then max int size to fail int parsing
 		// Database errors
-		{"List()", "/list", mockDatabaseRouter, http.StatusInternalServerError, []byte("Something went wrong\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}},
-		{"List()", "/v2/list", mockDatabaseRouter, http.StatusInternalServerError, []byte("Something went wrong\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}},
-		{"GetRandom()", "/200", mockDatabaseRouter, http.StatusInternalServerError, []byte("Something went wrong\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}},
-		{"GetRandom()", "/g/200", mockDatabaseRouter, http.StatusInternalServerError, []byte("Something went wrong\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}},
-		{"GetRandomWithSeed()", "/seed/1/200", mockDatabaseRouter, http.StatusInternalServerError, []byte("Something went wrong\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}},
-		{"Get() database", "/id/1/100/100", mockDatabaseRouter, http.StatusInternalServerError, []byte("Something went wrong\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}},
-		{"Get() database", "/g/100?image=1", mockDatabaseRouter, http.StatusInternalServerError, []byte("Something went wrong\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}},
-		{"Get() database info", "/id/1/info", mockDatabaseRouter, http.StatusInternalServerError, []byte("Something went wrong\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}},
+		{"List()", "/list", mockDatabaseRouter, http.StatusInternalServerError, []byte("Something went wrong\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}, ""},
+		{"Query()", "/v2/list", mockDatabaseRouter, http.StatusInternalServerError, []byte("Something went wrong\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}, ""},
+		{"GetRandom()", "/200", mockDatabaseRouter, http.StatusInternalServerError, []byte("Something went wrong\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}, ""},
+		{"GetRandom()", "/g/200", mockDatabaseRouter, http.StatusInternalServerError, []byte("Something went wrong\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}, ""},
+		{"GetRandomWithSeed()", "/seed/1/200", mockDatabaseRouter, http.StatusInternalServerError, []byte("Something went wrong\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}, ""},
+		{"Get() database", "/id/1/100/100", mockDatabaseRouter, http.StatusInternalServerError, []byte("Something went wrong\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}, ""},
+		{"Get() database", "/g/100?image=1", mockDatabaseRouter, http.StatusInternalServerError, []byte("Something went wrong\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}, ""},
+		{"Get() database info", "/id/1/info", mockDatabaseRouter, http.StatusInternalServerError, []byte("Something went wrong\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}, ""},
 		// 404
-		{"404", "/asdf", router, http.StatusNotFound, []byte("page not found\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}},
+		{"404", "/asdf", router, http.StatusNotFound, []byte("page not found\n"), map[string]string{"Content-Type": "text/plain; charset=utf-8", "Cache-Control": "private, no-cache, no-store, must-revalidate"}, ""},
 	}
 
 	for _, test := range tests {
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest("GET", test.URL, nil)
+		if test.Authorization != "" {
+			req.Header.Set("Authorization", test.Authorization)
+		}
 		test.Router.ServeHTTP(w, req)
 		if w.Code != test.ExpectedStatus {
 			t.Errorf("%s: wrong response code, %#v", test.Name, w.Code)
@@ -323,6 +837,11 @@ func TestAPI(t *testing.T) {
 		{"/id/:id/:size?blur", "/id/1/200?blur=10", "/id/1/200/200.jpg?blur=10", cacheableHeader, false},
 		{"/id/:id/:size?grayscale", "/id/1/200?grayscale", "/id/1/200/200.jpg?grayscale", cacheableHeader, false},
 		{"/id/:id/:size?blur&grayscale", "/id/1/200?blur&grayscale", "/id/1/200/200.jpg?blur=5&grayscale", cacheableHeader, false},
+		{"/id/:id/:width/:height?crop=face", "/id/1/200/200?crop=face", "/id/1/200/200.jpg?crop=face", cacheableHeader, false},
+		{"/id/:id/:width/:height?crop=entropy", "/id/1/200/200?crop=entropy", "/id/1/200/200.jpg?crop=entropy", cacheableHeader, false},
+		{"/id/:id/:width/:height?crop=smart", "/id/1/200/200?crop=smart", "/id/1/200/200.jpg?crop=smart", cacheableHeader, false},
+		{"/id/:id/:width/:height?blur&crop=face", "/id/1/200/200?blur&crop=face", "/id/1/200/200.jpg?blur=5&crop=face", cacheableHeader, false},
+		{"/id/:id/:width/:height?blur&crop=smart", "/id/1/200/200?blur&crop=smart", "/id/1/200/200.jpg?blur=5&crop=smart", cacheableHeader, false},
 
 		// General (random - not cacheable)
 		{"/:size", "/200", "/id/1/200/200.jpg", noCacheHeader, false},
@@ -354,6 +873,11 @@ func TestAPI(t *testing.T) {
 		{"width/height larger then max allowed but same size as image", "/id/1/300/400.webp", "/id/1/300/400.webp", cacheableHeader, false},
 		{"width/height of 0 returns original image width", "/id/1/0/0.webp", "/id/1/300/400.webp", cacheableHeader, false},
 
+		// AVIF (cacheable - deterministic)
+		{"/id/:id/:width/:height.avif", "/id/1/200/120.avif", "/id/1/200/120.avif", cacheableHeader, false},
+		{"/id/:id/:width/:height.avif?blur", "/id/1/200/200.avif?blur", "/id/1/200/200.avif?blur=5", cacheableHeader, false},
+		{"/id/:id/:width/:height.avif?grayscale", "/id/1/200/200.avif?grayscale", "/id/1/200/200.avif?grayscale", cacheableHeader, false},
+
 		// Default blur amount (random - not cacheable)
 		{"/:size?blur", "/200?blur", "/id/1/200/200.jpg?blur=5", noCacheHeader, false},
 		{"/:width/:height?blur", "/200/300?blur", "/id/1/200/300.jpg?blur=5", noCacheHeader, false},
@@ -454,6 +978,284 @@ func TestAPI(t *testing.T) {
 				t.Errorf("%s: wrong cache header, got %#v, expected %#v", test.Name, cacheControl, test.ExpectedCacheHeader)
 			}
 		}
+
+		if !test.LocalRedirect {
+			expectedDigest := fingerprint.Digest(test.ExpectedURL)
+			if digest := w.Header().Get("Digest"); digest != expectedDigest {
+				t.Errorf("%s: wrong digest header, got %#v, expected %#v", test.Name, digest, expectedDigest)
+			}
+
+			if etag := w.Header().Get("ETag"); etag == "" {
+				t.Errorf("%s: missing etag header", test.Name)
+			}
+		}
+	}
+
+	// Requests that only differ by the order of their query parameters
+	// resolve to the same canonicalized target, and so get the same digest.
+	reordered := httptest.NewRecorder()
+	reorderedReq, _ := http.NewRequest("GET", "/id/1/200/200?grayscale&blur", nil)
+	router.ServeHTTP(reordered, reorderedReq)
+
+	canonical := httptest.NewRecorder()
+	canonicalReq, _ := http.NewRequest("GET", "/id/1/200/200?blur&grayscale", nil)
+	router.ServeHTTP(canonical, canonicalReq)
+
+	if reordered.Header().Get("Digest") != canonical.Header().Get("Digest") {
+		t.Errorf("reordered query parameters produced different digests, %#v != %#v", reordered.Header().Get("Digest"), canonical.Header().Get("Digest"))
+	}
+
+	if reordered.Header().Get("ETag") != canonical.Header().Get("ETag") {
+		t.Errorf("reordered query parameters produced different etags, %#v != %#v", reordered.Header().Get("ETag"), canonical.Header().Get("ETag"))
+	}
+
+	// The fingerprint from a resolved redirect can be used on its own to
+	// reach the same signed, canonical image-service URL.
+	fingerprintURL := fmt.Sprintf("/f/%s.jpg", strings.Trim(canonical.Header().Get("ETag"), `"`))
+
+	fingerprintRedirect := httptest.NewRecorder()
+	fingerprintReq, _ := http.NewRequest("GET", fingerprintURL, nil)
+	router.ServeHTTP(fingerprintRedirect, fingerprintReq)
+
+	if fingerprintRedirect.Code != http.StatusFound {
+		t.Errorf("fingerprint redirect: wrong response code, %#v", fingerprintRedirect.Code)
+	}
+
+	if fingerprintRedirect.Header().Get("Location") != canonical.Header().Get("Location") {
+		t.Errorf("fingerprint redirect: wrong location, got %#v, expected %#v", fingerprintRedirect.Header().Get("Location"), canonical.Header().Get("Location"))
+	}
+
+	// A matching If-None-Match short-circuits to 304, both on the original
+	// redirect and its fingerprint alias.
+	notModified := httptest.NewRecorder()
+	notModifiedReq, _ := http.NewRequest("GET", "/id/1/200/200?blur&grayscale", nil)
+	notModifiedReq.Header.Set("If-None-Match", canonical.Header().Get("ETag"))
+	router.ServeHTTP(notModified, notModifiedReq)
+
+	if notModified.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match: wrong response code, %#v", notModified.Code)
+	}
+
+	fingerprintNotModified := httptest.NewRecorder()
+	fingerprintNotModifiedReq, _ := http.NewRequest("GET", fingerprintURL, nil)
+	fingerprintNotModifiedReq.Header.Set("If-None-Match", canonical.Header().Get("ETag"))
+	router.ServeHTTP(fingerprintNotModified, fingerprintNotModifiedReq)
+
+	if fingerprintNotModified.Code != http.StatusNotModified {
+		t.Errorf("fingerprint If-None-Match: wrong response code, %#v", fingerprintNotModified.Code)
+	}
+
+	// With no explicit extension, the format is negotiated from the Accept
+	// header, and the negotiated extension is part of the signed target.
+	negotiationTests := []struct {
+		Name        string
+		Accept      string
+		ExpectedURL string
+	}{
+		{"no accept header falls back to jpg", "", "/id/1/200/200.jpg"},
+		{"unsupported accept header falls back to jpg", "text/html", "/id/1/200/200.jpg"},
+		{"accept webp", "image/webp", "/id/1/200/200.webp"},
+		{"accept avif", "image/avif", "/id/1/200/200.avif"},
+		{"accept avif and webp prefers avif", "image/webp;q=0.8, image/avif;q=0.9", "/id/1/200/200.avif"},
+	}
+
+	for _, test := range negotiationTests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/id/1/200/200", nil)
+		if test.Accept != "" {
+			req.Header.Set("Accept", test.Accept)
+		}
+		router.ServeHTTP(w, req)
+
+		expectedHMAC, err := hmac.Create(test.ExpectedURL)
+		if err != nil {
+			t.Errorf("%s: hmac error %s", test.Name, err)
+			continue
+		}
+
+		expectedLocation := imageServiceURL + test.ExpectedURL + "?hmac=" + expectedHMAC
+		if location := w.Header().Get("Location"); location != expectedLocation {
+			t.Errorf("%s: wrong redirect %s, expected %s", test.Name, location, expectedLocation)
+		}
+
+		if vary := w.Header().Get("Vary"); vary != "Accept" {
+			t.Errorf("%s: wrong Vary header %#v, expected %#v", test.Name, vary, "Accept")
+		}
+	}
+
+	// crop=smart is deterministic for a given (id, width, height): repeated
+	// requests produce a byte-identical signed redirect, so the window the
+	// image-processing service picks stays cacheable.
+	smartFirst := httptest.NewRecorder()
+	smartFirstReq, _ := http.NewRequest("GET", "/id/1/200/200?crop=smart", nil)
+	router.ServeHTTP(smartFirst, smartFirstReq)
+
+	smartSecond := httptest.NewRecorder()
+	smartSecondReq, _ := http.NewRequest("GET", "/id/1/200/200?crop=smart", nil)
+	router.ServeHTTP(smartSecond, smartSecondReq)
+
+	if smartFirst.Header().Get("Location") != smartSecond.Header().Get("Location") {
+		t.Errorf("crop=smart: non-deterministic redirect, %#v != %#v", smartFirst.Header().Get("Location"), smartSecond.Header().Get("Location"))
+	}
+
+	if !strings.Contains(smartFirst.Header().Get("Location"), "crop=smart") {
+		t.Errorf("crop=smart: parameter not preserved through the hmac round-trip, got %#v", smartFirst.Header().Get("Location"))
+	}
+
+	// An unregistered fingerprint isn't resolvable.
+	unknownFingerprint := httptest.NewRecorder()
+	unknownFingerprintReq, _ := http.NewRequest("GET", "/f/0000000000000000.jpg", nil)
+	router.ServeHTTP(unknownFingerprint, unknownFingerprintReq)
+
+	if unknownFingerprint.Code != http.StatusNotFound {
+		t.Errorf("unknown fingerprint: wrong response code, %#v", unknownFingerprint.Code)
+	}
+}
+
+// TestSignedURLTTL covers the redirect handler's propagation of a signed
+// exp parameter and its bounded Cache-Control header, alongside the signer
+// package's own validation of the resulting target.
+func TestSignedURLTTL(t *testing.T) {
+	log := logger.New(zap.FatalLevel)
+	defer log.Sync()
+
+	db, _ := fileDatabase.New("../../test/fixtures/file/metadata.json")
+
+	hmacKey := &hmac.HMAC{
+		Key: []byte("test"),
+	}
+
+	tp := trace.NewNoopTracerProvider()
+	tracer := &tracing.Tracer{
+		ServiceName:    "test",
+		Log:            log,
+		TracerProvider: tp,
+		ShutdownFunc: func(context.Context) error {
+			return nil
+		},
+	}
+
+	metadataService := metadata.New(&mockStorage.Provider{})
+	facesService := faces.New(&mockStorage.Provider{}, fakeDetector{}, faces.DefaultThresholds)
+
+	ttl := time.Minute
+	router, _ := (&api.API{db, log, tracer, rootURL, imageServiceURL, time.Minute, hmacKey, metadataService, facesService, fingerprint.New(), format.New(), ttl, nil}).Router()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/id/1/200/200", nil)
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusFound {
+		t.Fatalf("wrong response code, %#v", w.Code)
+	}
+
+	location := w.Header().Get("Location")
+	if !strings.Contains(location, "exp=") {
+		t.Fatalf("exp parameter not propagated into redirect, got %#v", location)
+	}
+
+	signedURL, err := url.Parse(location)
+	if err != nil {
+		t.Fatalf("unexpected error parsing redirect: %s", err)
+	}
+
+	query := signedURL.Query()
+	signature := query.Get("hmac")
+	query.Del("hmac")
+
+	target := signedURL.Path
+	if encoded := query.Encode(); encoded != "" {
+		target += "?" + encoded
+	}
+
+	if err := signer.Validate(hmacKey, target, signature, true); err != nil {
+		t.Errorf("unexpected error validating the signed redirect: %s", err)
+	}
+
+	expectedCacheControl := "public, max-age=60, stale-while-revalidate=60, stale-if-error=43200"
+	if cacheControl := w.Header().Get("Cache-Control"); cacheControl != expectedCacheControl {
+		t.Errorf("wrong cache header, got %#v, expected %#v", cacheControl, expectedCacheControl)
+	}
+}
+
+// TestTokenMintKey covers /v2/token's gating on the X-Token-Mint-Key header:
+// disabled when unconfigured, rejecting missing/wrong keys, and issuing a
+// token scoped to the requested author when the key matches.
+func TestTokenMintKey(t *testing.T) {
+	log := logger.New(zap.FatalLevel)
+	defer log.Sync()
+
+	db, _ := fileDatabase.New("../../test/fixtures/file/metadata.json")
+
+	hmacKey := &hmac.HMAC{
+		Key: []byte("test"),
+	}
+
+	tp := trace.NewNoopTracerProvider()
+	tracer := &tracing.Tracer{
+		ServiceName:    "test",
+		Log:            log,
+		TracerProvider: tp,
+		ShutdownFunc: func(context.Context) error {
+			return nil
+		},
+	}
+
+	metadataService := metadata.New(&mockStorage.Provider{})
+	facesService := faces.New(&mockStorage.Provider{}, fakeDetector{}, faces.DefaultThresholds)
+
+	unconfiguredRouter, _ := (&api.API{db, log, tracer, rootURL, imageServiceURL, time.Minute, hmacKey, metadataService, facesService, fingerprint.New(), format.New(), 0, nil}).Router()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v2/token", nil)
+	unconfiguredRouter.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unconfigured mint key: wrong response code, %#v", w.Code)
+	}
+
+	router, _ := (&api.API{db, log, tracer, rootURL, imageServiceURL, time.Minute, hmacKey, metadataService, facesService, fingerprint.New(), format.New(), 0, []byte("mint-secret")}).Router()
+
+	missingKey := httptest.NewRecorder()
+	missingKeyReq, _ := http.NewRequest("GET", "/v2/token", nil)
+	router.ServeHTTP(missingKey, missingKeyReq)
+
+	if missingKey.Code != http.StatusUnauthorized {
+		t.Errorf("missing mint key: wrong response code, %#v", missingKey.Code)
+	}
+
+	wrongKey := httptest.NewRecorder()
+	wrongKeyReq, _ := http.NewRequest("GET", "/v2/token", nil)
+	wrongKeyReq.Header.Set("X-Token-Mint-Key", "wrong-secret")
+	router.ServeHTTP(wrongKey, wrongKeyReq)
+
+	if wrongKey.Code != http.StatusUnauthorized {
+		t.Errorf("wrong mint key: wrong response code, %#v", wrongKey.Code)
+	}
+
+	issued := httptest.NewRecorder()
+	issuedReq, _ := http.NewRequest("GET", "/v2/token?author=John+Doe", nil)
+	issuedReq.Header.Set("X-Token-Mint-Key", "mint-secret")
+	router.ServeHTTP(issued, issuedReq)
+
+	if issued.Code != http.StatusOK {
+		t.Fatalf("valid mint key: wrong response code, %#v", issued.Code)
+	}
+
+	var body struct {
+		Token     string `json:"token"`
+		ExpiresIn int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(issued.Body.Bytes(), &body); err != nil {
+		t.Fatalf("unexpected error decoding response: %s", err)
+	}
+
+	claims, err := token.Parse(hmacKey, body.Token)
+	if err != nil {
+		t.Fatalf("unexpected error parsing issued token: %s", err)
+	}
+	if claims.AllowedAuthor != "John Doe" {
+		t.Errorf("wrong allowed author, got %#v", claims.AllowedAuthor)
 	}
 }
 
@@ -0,0 +1,24 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/DMarby/picsum-photos/internal/database"
+	"github.com/DMarby/picsum-photos/internal/storage/limit"
+)
+
+// writeError writes the appropriate HTTP status/body for an error returned by the database or storage.
+func writeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, database.ErrNotFound) {
+		http.Error(w, "Image does not exist", http.StatusNotFound)
+		return
+	}
+
+	if errors.Is(err, limit.ErrTooLarge) {
+		http.Error(w, "Image exceeds the maximum allowed size", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	http.Error(w, "Something went wrong", http.StatusInternalServerError)
+}
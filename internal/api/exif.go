@@ -0,0 +1,61 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/DMarby/picsum-photos/internal/storage/limit"
+)
+
+// storagePath returns the path of the original, stored image for the given id.
+func storagePath(id string) string {
+	return fmt.Sprintf("%s.jpg", id)
+}
+
+// exifHandler handles GET /id/{id}/exif and GET /seed/{seed}/exif.
+func (a *API) exifHandler(w http.ResponseWriter, r *http.Request) {
+	writeNoCacheHeaders(w)
+
+	id, ok := a.resolveID(w, r, mux.Vars(r))
+	if !ok {
+		return
+	}
+
+	image, err := a.Database.Get(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	exif, err := a.Metadata.Get(r.Context(), image.ID, storagePath(image.ID))
+	if err != nil {
+		if errors.Is(err, limit.ErrTooLarge) {
+			writeError(w, err)
+			return
+		}
+
+		http.Error(w, "No EXIF data", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, exif)
+}
+
+// resolveID resolves the {id} or {seed} route variable into a concrete image id.
+func (a *API) resolveID(w http.ResponseWriter, r *http.Request, vars map[string]string) (string, bool) {
+	if id, ok := vars["id"]; ok {
+		return id, true
+	}
+
+	seed := vars["seed"]
+	id, err := a.Database.GetRandomWithSeed(r.Context(), hashSeed(seed))
+	if err != nil {
+		writeError(w, err)
+		return "", false
+	}
+
+	return id, true
+}
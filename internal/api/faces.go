@@ -0,0 +1,31 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// facesHandler handles GET /id/{id}/faces and GET /seed/{seed}/faces.
+func (a *API) facesHandler(w http.ResponseWriter, r *http.Request) {
+	writeNoCacheHeaders(w)
+
+	id, ok := a.resolveID(w, r, mux.Vars(r))
+	if !ok {
+		return
+	}
+
+	image, err := a.Database.Get(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	detectedFaces, err := a.Faces.Get(r.Context(), image.ID, storagePath(image.ID))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, detectedFaces)
+}
@@ -0,0 +1,250 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/DMarby/picsum-photos/internal/database"
+)
+
+// hashSeed turns an arbitrary seed string into a deterministic int64 seed.
+// Numeric seeds are used as-is so that /seed/1/... is stable across releases;
+// anything else is hashed.
+func hashSeed(seed string) int64 {
+	if n, err := strconv.ParseInt(seed, 10, 64); err == nil {
+		return n
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(seed))
+	return int64(h.Sum32())
+}
+
+var validOrientations = map[string]bool{"landscape": true, "portrait": true, "square": true}
+var validSortFields = map[string]bool{"id": true, "width": true, "height": true, "author": true}
+
+// v2ListHandler handles GET /v2/list, returning a filtered, sorted and paginated list of images.
+func (a *API) v2ListHandler(w http.ResponseWriter, r *http.Request) {
+	writeNoCacheHeaders(w)
+
+	opts, ok := parseQueryOptions(w, r)
+	if !ok {
+		return
+	}
+
+	if claims, ok := claimsFromContext(r.Context()); ok && claims.AllowedAuthor != "" {
+		opts.Author = claims.AllowedAuthor
+	}
+
+	images, err := a.Database.Query(r.Context(), opts)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	result := make([]ListImage, 0, len(images))
+	for _, image := range images {
+		result = append(result, a.toListImage(image))
+	}
+
+	links := []string{}
+	if opts.Page > 1 {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, a.listURL(opts, opts.Page-1)))
+	}
+	if len(images) > 0 {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, a.listURL(opts, opts.Page+1)))
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+		w.Header().Set("Access-Control-Expose-Headers", "Link")
+	}
+
+	writeJSON(w, result)
+}
+
+// parseQueryOptions reads and validates the filter/sort/pagination query parameters for /v2/list.
+func parseQueryOptions(w http.ResponseWriter, r *http.Request) (database.QueryOptions, bool) {
+	query := r.URL.Query()
+
+	opts := database.QueryOptions{
+		Page:   1,
+		Limit:  defaultLimit,
+		Author: query.Get("author"),
+		Sort:   "id",
+		Order:  "asc",
+	}
+
+	if p := query.Get("page"); p != "" {
+		if parsed, err := strconv.Atoi(p); err == nil && parsed > 0 {
+			opts.Page = parsed
+		}
+	}
+
+	if l := query.Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
+			opts.Limit = parsed
+		}
+	}
+	if opts.Limit > maxLimit {
+		opts.Limit = maxLimit
+	}
+
+	if orientation := query.Get("orientation"); orientation != "" {
+		if !validOrientations[orientation] {
+			http.Error(w, "Invalid orientation", http.StatusBadRequest)
+			return opts, false
+		}
+		opts.Orientation = orientation
+	}
+
+	for param, dest := range map[string]*int{
+		"min_width":  &opts.MinWidth,
+		"min_height": &opts.MinHeight,
+		"max_width":  &opts.MaxWidth,
+		"max_height": &opts.MaxHeight,
+	} {
+		if raw := query.Get(param); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil || parsed < 0 {
+				http.Error(w, "Invalid "+param, http.StatusBadRequest)
+				return opts, false
+			}
+			*dest = parsed
+		}
+	}
+
+	if sort := query.Get("sort"); sort != "" {
+		if !validSortFields[sort] {
+			http.Error(w, "Invalid sort", http.StatusBadRequest)
+			return opts, false
+		}
+		opts.Sort = sort
+	}
+
+	if order := query.Get("order"); order != "" {
+		if order != "asc" && order != "desc" {
+			http.Error(w, "Invalid order", http.StatusBadRequest)
+			return opts, false
+		}
+		opts.Order = order
+	}
+
+	return opts, true
+}
+
+// listURL builds the /v2/list URL for the given page, echoing the active filter/sort parameters.
+func (a *API) listURL(opts database.QueryOptions, page int) string {
+	params := url.Values{}
+	params.Set("page", strconv.Itoa(page))
+	params.Set("limit", strconv.Itoa(opts.Limit))
+
+	if opts.Author != "" {
+		params.Set("author", opts.Author)
+	}
+	if opts.Orientation != "" {
+		params.Set("orientation", opts.Orientation)
+	}
+	if opts.MinWidth != 0 {
+		params.Set("min_width", strconv.Itoa(opts.MinWidth))
+	}
+	if opts.MinHeight != 0 {
+		params.Set("min_height", strconv.Itoa(opts.MinHeight))
+	}
+	if opts.MaxWidth != 0 {
+		params.Set("max_width", strconv.Itoa(opts.MaxWidth))
+	}
+	if opts.MaxHeight != 0 {
+		params.Set("max_height", strconv.Itoa(opts.MaxHeight))
+	}
+	if opts.Sort != "id" {
+		params.Set("sort", opts.Sort)
+	}
+	if opts.Order != "asc" {
+		params.Set("order", opts.Order)
+	}
+
+	return fmt.Sprintf("%s/v2/list?%s", a.RootURL, params.Encode())
+}
+
+// deprecatedListHandler handles GET /list, the legacy unpaginated listing endpoint.
+func (a *API) deprecatedListHandler(w http.ResponseWriter, r *http.Request) {
+	writeNoCacheHeaders(w)
+
+	count, err := a.Database.Count(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	images, err := a.Database.List(r.Context(), 1, count)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	result := make([]DeprecatedImage, 0, len(images))
+	for i, image := range images {
+		result = append(result, DeprecatedImage{
+			Format:    "jpeg",
+			Width:     image.Width,
+			Height:    image.Height,
+			Filename:  fmt.Sprintf("%d.jpeg", i+1),
+			ID:        i + 1,
+			Author:    image.Author,
+			AuthorURL: image.URL,
+			PostURL:   image.URL,
+		})
+	}
+
+	writeJSON(w, result)
+}
+
+// infoHandler handles GET /id/{id}/info and GET /seed/{seed}/info.
+// An `?include=exif` query parameter inlines the image's EXIF data, if any.
+func (a *API) infoHandler(w http.ResponseWriter, r *http.Request) {
+	writeNoCacheHeaders(w)
+
+	id, ok := a.resolveID(w, r, mux.Vars(r))
+	if !ok {
+		return
+	}
+
+	image, err := a.Database.Get(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	result := a.toListImage(image)
+	if r.URL.Query().Get("include") == "exif" {
+		if exif, err := a.Metadata.Get(r.Context(), image.ID, storagePath(image.ID)); err == nil {
+			result.Exif = exif
+		}
+	}
+
+	writeJSON(w, result)
+}
+
+func (a *API) toListImage(image database.Image) ListImage {
+	return ListImage{
+		Image:       image,
+		DownloadURL: fmt.Sprintf("%s/id/%s/%d/%d", a.RootURL, image.ID, image.Width, image.Height),
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Write(append(encoded, '\n'))
+}
@@ -0,0 +1,243 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/DMarby/picsum-photos/internal/fingerprint"
+	"github.com/DMarby/picsum-photos/internal/signer"
+)
+
+const (
+	cacheableMaxAge       = 86400
+	cacheableCacheControl = "public, max-age=86400, stale-while-revalidate=60, stale-if-error=43200"
+	noCacheCacheControl   = "private, no-cache, no-store, must-revalidate"
+)
+
+// registerImageRoutes registers the routes that resolve an image request and
+// redirect it, HMAC-signed, to the image processing service.
+func (a *API) registerImageRoutes(r *mux.Router) {
+	segment := `{size:[0-9]+}{ext:(?:\.[a-zA-Z0-9]+)?}`
+	widthHeight := `{width:[0-9]+}/{height:[0-9]+}{ext:(?:\.[a-zA-Z0-9]+)?}`
+
+	r.HandleFunc("/"+segment, a.redirectHandler(false)).Methods(http.MethodGet)
+	r.HandleFunc("/"+widthHeight, a.redirectHandler(false)).Methods(http.MethodGet)
+	r.HandleFunc("/id/{id}/"+segment, a.redirectHandler(false)).Methods(http.MethodGet)
+	r.HandleFunc("/id/{id}/"+widthHeight, a.redirectHandler(false)).Methods(http.MethodGet)
+	r.HandleFunc("/seed/{seed}/"+segment, a.redirectHandler(false)).Methods(http.MethodGet)
+	r.HandleFunc("/seed/{seed}/"+widthHeight, a.redirectHandler(false)).Methods(http.MethodGet)
+	r.HandleFunc("/g/"+segment, a.redirectHandler(true)).Methods(http.MethodGet)
+	r.HandleFunc("/g/"+widthHeight, a.redirectHandler(true)).Methods(http.MethodGet)
+
+	r.HandleFunc(`/f/{fingerprint:[0-9a-f]{16}}{ext:(?:\.[a-zA-Z0-9]+)?}`, a.fingerprintHandler).Methods(http.MethodGet)
+}
+
+// redirectHandler resolves an image request (random, by id or by seed) and
+// redirects it to the signed, canonical image-service URL.
+func (a *API) redirectHandler(grayscale bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeNoCacheHeaders(w)
+		w.Header().Set("Vary", "Accept")
+
+		vars := mux.Vars(r)
+		ctx := r.Context()
+
+		cacheable := true
+		id, idOk := vars["id"]
+
+		if !idOk {
+			if seed, ok := vars["seed"]; ok {
+				resolved, err := a.Database.GetRandomWithSeed(ctx, hashSeed(seed))
+				if err != nil {
+					writeError(w, err)
+					return
+				}
+				id = resolved
+			} else if image := r.URL.Query().Get("image"); image != "" {
+				// Deprecated ?image=:id query parameter.
+				cacheable = false
+				id = image
+			} else {
+				cacheable = false
+				resolved, err := a.Database.GetRandom(ctx)
+				if err != nil {
+					writeError(w, err)
+					return
+				}
+				id = resolved
+			}
+		}
+
+		image, err := a.Database.Get(ctx, id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		width, height, ok := a.parseSize(w, vars)
+		if !ok {
+			return
+		}
+		if width == 0 && height == 0 {
+			width, height = image.Width, image.Height
+		}
+
+		ext := vars["ext"]
+		if ext == "" {
+			ext = a.Formats.Negotiate(r.Header.Get("Accept")).Extension
+		} else if _, ok := a.Formats.Lookup(ext); !ok {
+			http.Error(w, "Invalid file extension", http.StatusBadRequest)
+			return
+		}
+
+		params := []string{}
+
+		if _, hasBlur := r.URL.Query()["blur"]; hasBlur {
+			amount := defaultBlur
+			if raw := r.URL.Query().Get("blur"); raw != "" {
+				parsed, err := strconv.Atoi(raw)
+				if err != nil || parsed < minBlur || parsed > maxBlur {
+					http.Error(w, "Invalid blur amount", http.StatusBadRequest)
+					return
+				}
+				amount = parsed
+			}
+			params = append(params, fmt.Sprintf("blur=%d", amount))
+		}
+
+		if grayscale {
+			params = append(params, "grayscale")
+		} else if _, hasGrayscale := r.URL.Query()["grayscale"]; hasGrayscale {
+			params = append(params, "grayscale")
+		}
+
+		if crop := r.URL.Query().Get("crop"); crop != "" {
+			if !validCropModes[crop] {
+				http.Error(w, "Invalid crop mode", http.StatusBadRequest)
+				return
+			}
+			params = append(params, "crop="+crop)
+		}
+
+		target := fmt.Sprintf("/id/%s/%d/%d%s", image.ID, width, height, ext)
+		if len(params) > 0 {
+			target += "?" + strings.Join(params, "&")
+		}
+
+		signedTarget, signature, err := signer.SignWithExpiry(a.HMAC, target, a.SignedURLTTL)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		separator := "?"
+		if strings.Contains(signedTarget, "?") {
+			separator = "&"
+		}
+
+		if cacheable {
+			w.Header().Set("Cache-Control", a.cacheableCacheControl())
+		} else {
+			w.Header().Set("Cache-Control", noCacheCacheControl)
+		}
+
+		etag := `"` + a.Fingerprint.Register(target) + `"`
+		w.Header().Set("Digest", fingerprint.Digest(target))
+		w.Header().Set("ETag", etag)
+
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		http.Redirect(w, r, a.ImageServiceURL+signedTarget+separator+"hmac="+signature, http.StatusFound)
+	}
+}
+
+// fingerprintHandler handles GET /f/{fingerprint}, redirecting a previously
+// registered content fingerprint to the same signed, canonical image-service
+// URL its originating request would have produced.
+func (a *API) fingerprintHandler(w http.ResponseWriter, r *http.Request) {
+	writeNoCacheHeaders(w)
+
+	vars := mux.Vars(r)
+
+	target, ok := a.Fingerprint.Lookup(vars["fingerprint"])
+	if !ok {
+		http.Error(w, "Unknown fingerprint", http.StatusNotFound)
+		return
+	}
+
+	signedTarget, signature, err := signer.SignWithExpiry(a.HMAC, target, a.SignedURLTTL)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	separator := "?"
+	if strings.Contains(signedTarget, "?") {
+		separator = "&"
+	}
+
+	etag := `"` + vars["fingerprint"] + `"`
+	w.Header().Set("Cache-Control", a.cacheableCacheControl())
+	w.Header().Set("Digest", fingerprint.Digest(target))
+	w.Header().Set("ETag", etag)
+
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	http.Redirect(w, r, a.ImageServiceURL+signedTarget+separator+"hmac="+signature, http.StatusFound)
+}
+
+// cacheableCacheControl returns the Cache-Control value for a cacheable
+// redirect. When SignedURLTTL is set, max-age is bounded by it so
+// intermediaries don't keep serving a redirect past the expiry embedded in
+// its signed target.
+func (a *API) cacheableCacheControl() string {
+	if a.SignedURLTTL <= 0 {
+		return cacheableCacheControl
+	}
+
+	maxAge := int(a.SignedURLTTL.Seconds())
+	if maxAge > cacheableMaxAge {
+		maxAge = cacheableMaxAge
+	}
+
+	return fmt.Sprintf("public, max-age=%d, stale-while-revalidate=60, stale-if-error=43200", maxAge)
+}
+
+// parseSize reads the size (or width/height) route variables and validates them.
+func (a *API) parseSize(w http.ResponseWriter, vars map[string]string) (width int, height int, ok bool) {
+	parse := func(raw string) (int, bool) {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 || n > maxImageSize {
+			http.Error(w, "Invalid size", http.StatusBadRequest)
+			return 0, false
+		}
+		return n, true
+	}
+
+	if size, present := vars["size"]; present {
+		n, valid := parse(size)
+		return n, n, valid
+	}
+
+	w2, valid := parse(vars["width"])
+	if !valid {
+		return 0, 0, false
+	}
+
+	h2, valid := parse(vars["height"])
+	if !valid {
+		return 0, 0, false
+	}
+
+	return w2, h2, true
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DMarby/picsum-photos/internal/token"
+)
+
+// Default/max TTL for issued tokens.
+const (
+	defaultTokenTTL = 5 * time.Minute
+	maxTokenTTL     = time.Hour
+)
+
+type claimsContextKey struct{}
+
+// tokenHandler handles GET /v2/token, issuing a short-lived bearer token.
+//
+// Issuance is an operator action, not a client one: the caller's own query
+// parameters choose the token's scope (AllowedAuthor), so an unauthenticated
+// caller could otherwise mint itself an unrestricted token. The request must
+// carry the X-Token-Mint-Key header matching a.TokenMintKey. If TokenMintKey
+// isn't configured, minting is disabled entirely.
+func (a *API) tokenHandler(w http.ResponseWriter, r *http.Request) {
+	writeNoCacheHeaders(w)
+
+	if len(a.TokenMintKey) == 0 {
+		http.Error(w, "page not found", http.StatusNotFound)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Token-Mint-Key")), a.TokenMintKey) != 1 {
+		http.Error(w, "Invalid mint key", http.StatusUnauthorized)
+		return
+	}
+
+	ttl := defaultTokenTTL
+	if raw := r.URL.Query().Get("ttl"); raw != "" {
+		seconds, err := strconv.Atoi(raw)
+		if err != nil || seconds <= 0 {
+			http.Error(w, "Invalid ttl", http.StatusBadRequest)
+			return
+		}
+		ttl = time.Duration(seconds) * time.Second
+	}
+	if ttl > maxTokenTTL {
+		ttl = maxTokenTTL
+	}
+
+	claims := token.Claims{
+		AllowedAuthor: r.URL.Query().Get("author"),
+	}
+
+	signed, err := token.Issue(a.HMAC, claims, ttl)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, struct {
+		Token     string `json:"token"`
+		ExpiresIn int    `json:"expires_in"`
+	}{
+		Token:     signed,
+		ExpiresIn: int(ttl.Seconds()),
+	})
+}
+
+// withAuth validates an optional `Authorization: Bearer <token>` header, rejecting
+// requests that carry a malformed, expired or incorrectly signed token. Requests
+// without the header are treated as anonymous and pass through unchanged.
+func (a *API) withAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			next(w, r)
+			return
+		}
+
+		raw, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok {
+			writeNoCacheHeaders(w)
+			http.Error(w, "Invalid authorization header", http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := token.Parse(a.HMAC, raw)
+		if err != nil {
+			writeNoCacheHeaders(w)
+			http.Error(w, "Invalid bearer token", http.StatusUnauthorized)
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), claimsContextKey{}, claims)))
+	}
+}
+
+// claimsFromContext returns the token claims attached by withAuth, if any.
+func claimsFromContext(ctx context.Context) (token.Claims, bool) {
+	claims, ok := ctx.Value(claimsContextKey{}).(token.Claims)
+	return claims, ok
+}
@@ -2,11 +2,26 @@ package cmd
 
 import (
 	"time"
+
+	"github.com/DMarby/picsum-photos/internal/storage/limit"
+	"github.com/DMarby/picsum-photos/internal/timeout"
 )
 
 // Http timeouts
 const (
-	ReadTimeout    = 30 * time.Second
-	WriteTimeout   = 90 * time.Second
-	HandlerTimeout = 45 * time.Second
+	ReadTimeout  = 30 * time.Second
+	WriteTimeout = 90 * time.Second
 )
+
+// HandlerTimeout was previously a fixed 45s budget for every handler. It's
+// now adaptive, tracked per route by internal/timeout.Tracker, so it can
+// grow or decay with the actual cost of the requested transform instead of
+// forcing one number to fit every route. DefaultHandlerTimeoutConfig carries
+// the equivalent starting point and bounds for that tracker.
+var DefaultHandlerTimeoutConfig = timeout.DefaultConfig
+
+// MaxAssetBytes bounds the size of a source image fetched from storage
+// before it's handed to the face-detection or EXIF decoders, so a single
+// oversized asset can't be decoded into memory and blow past
+// DefaultHandlerTimeoutConfig trying.
+const MaxAssetBytes = limit.DefaultMaxBytes
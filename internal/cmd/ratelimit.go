@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+// RouteClass buckets requests by relative cost, so a handful of clients
+// hammering expensive transforms can't starve the quota cheap, cacheable
+// requests get.
+type RouteClass string
+
+const (
+	// RouteClassCheap is for cached, already-rendered lookups, e.g. /id/....
+	RouteClassCheap RouteClass = "cheap"
+
+	// RouteClassExpensive is for large or custom transforms that have to be
+	// rendered on demand.
+	RouteClassExpensive RouteClass = "expensive"
+)
+
+// RateLimitConfig configures a RateLimiter's per-client, per-route-class
+// token buckets.
+type RateLimitConfig struct {
+	CheapQPS   float64
+	CheapBurst int
+
+	ExpensiveQPS   float64
+	ExpensiveBurst int
+
+	// EvictAfter is how long a client's buckets are kept after their last
+	// request before the background sweep evicts them.
+	EvictAfter time.Duration
+
+	// EvictInterval is how often the background sweep runs.
+	EvictInterval time.Duration
+}
+
+// DefaultRateLimitConfig is a reasonable starting point for a public-facing
+// image service: generous for cached lookups, tight for custom transforms.
+var DefaultRateLimitConfig = RateLimitConfig{
+	CheapQPS:       20,
+	CheapBurst:     40,
+	ExpensiveQPS:   2,
+	ExpensiveBurst: 4,
+	EvictAfter:     10 * time.Minute,
+	EvictInterval:  time.Minute,
+}
+
+// clientKey identifies a client's token bucket: its address plus the route
+// class it's being charged against.
+type clientKey struct {
+	addr  string
+	class RouteClass
+}
+
+type clientBucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// RateLimiter enforces per-client, per-route-class token bucket rate limits,
+// evicting idle clients in the background so the client map doesn't grow
+// unbounded under scraping from many distinct addresses.
+type RateLimiter struct {
+	cfg RateLimitConfig
+	log *zap.Logger
+
+	mtx     sync.Mutex
+	clients map[clientKey]*clientBucket
+
+	stop chan struct{}
+}
+
+// NewRateLimiter returns a RateLimiter configured per cfg and starts its
+// background eviction sweep. Call Close to stop the sweep.
+func NewRateLimiter(cfg RateLimitConfig, log *zap.Logger) *RateLimiter {
+	rl := &RateLimiter{
+		cfg:     cfg,
+		log:     log,
+		clients: make(map[clientKey]*clientBucket),
+		stop:    make(chan struct{}),
+	}
+
+	go rl.evictLoop()
+
+	return rl
+}
+
+// Close stops the background eviction sweep.
+func (rl *RateLimiter) Close() {
+	close(rl.stop)
+}
+
+// Middleware rate-limits requests per client and the route class classify
+// assigns them to, rejecting exhausted clients with 429 and a Retry-After
+// header derived from the bucket's refill time.
+func (rl *RateLimiter) Middleware(classify func(r *http.Request) RouteClass) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			class := classify(r)
+			addr := clientAddr(r)
+			key := clientKey{addr: addr, class: class}
+
+			reservation := rl.limiterFor(key).Reserve()
+			if !reservation.OK() {
+				http.Error(w, "Request rejected by rate limit configuration", http.StatusInternalServerError)
+				return
+			}
+
+			if delay := reservation.Delay(); delay > 0 {
+				reservation.Cancel()
+
+				retryAfter := int(math.Ceil(delay.Seconds()))
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+				http.Error(w, "Too many requests", http.StatusTooManyRequests)
+
+				rl.log.Info("rate limit exceeded",
+					zap.String("client", addr),
+					zap.String("route_class", string(class)),
+					zap.Int("retry_after_seconds", retryAfter),
+				)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (rl *RateLimiter) limiterFor(key clientKey) *rate.Limiter {
+	rl.mtx.Lock()
+	defer rl.mtx.Unlock()
+
+	b, ok := rl.clients[key]
+	if !ok {
+		qps, burst := rl.cfg.bucketFor(key.class)
+		b = &clientBucket{limiter: rate.NewLimiter(rate.Limit(qps), burst)}
+		rl.clients[key] = b
+	}
+	b.lastSeen = time.Now()
+
+	return b.limiter
+}
+
+func (cfg RateLimitConfig) bucketFor(class RouteClass) (qps float64, burst int) {
+	if class == RouteClassExpensive {
+		return cfg.ExpensiveQPS, cfg.ExpensiveBurst
+	}
+	return cfg.CheapQPS, cfg.CheapBurst
+}
+
+func (rl *RateLimiter) evictLoop() {
+	ticker := time.NewTicker(rl.cfg.EvictInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			rl.evict()
+		case <-rl.stop:
+			return
+		}
+	}
+}
+
+func (rl *RateLimiter) evict() {
+	cutoff := time.Now().Add(-rl.cfg.EvictAfter)
+
+	rl.mtx.Lock()
+	defer rl.mtx.Unlock()
+
+	for key, b := range rl.clients {
+		if b.lastSeen.Before(cutoff) {
+			delete(rl.clients, key)
+		}
+	}
+}
+
+// clientAddr identifies the requesting client, preferring the first hop of
+// X-Forwarded-For (as set by the reverse proxy this service runs behind)
+// and falling back to the raw connection's RemoteAddr.
+func clientAddr(r *http.Request) string {
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		if comma := strings.IndexByte(xff, ','); comma != -1 {
+			return strings.TrimSpace(xff[:comma])
+		}
+		return strings.TrimSpace(xff)
+	}
+
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+
+	return r.RemoteAddr
+}
+
+// ClassifyBySize returns a classify function, for use with Middleware, that
+// treats a request as RouteClassExpensive when its width/height query
+// parameters multiply out to more than maxCheapPixels, and
+// RouteClassCheap otherwise.
+func ClassifyBySize(maxCheapPixels int) func(r *http.Request) RouteClass {
+	return func(r *http.Request) RouteClass {
+		width, height := dimension(r, "width"), dimension(r, "height")
+		if width*height > maxCheapPixels {
+			return RouteClassExpensive
+		}
+		return RouteClassCheap
+	}
+}
+
+func dimension(r *http.Request, param string) int {
+	raw := r.URL.Query().Get(param)
+	if raw == "" {
+		return 0
+	}
+
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		return 0
+	}
+
+	return n
+}
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/DMarby/picsum-photos/internal/logger"
+)
+
+func testRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		CheapQPS:       1,
+		CheapBurst:     2,
+		ExpensiveQPS:   1,
+		ExpensiveBurst: 1,
+		EvictAfter:     time.Minute,
+		EvictInterval:  time.Minute,
+	}
+}
+
+func serveRateLimited(rl *RateLimiter, class RouteClass, addr string) *httptest.ResponseRecorder {
+	handler := rl.Middleware(func(r *http.Request) RouteClass { return class })(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Forwarded-For", addr)
+	handler.ServeHTTP(w, req)
+
+	return w
+}
+
+func TestMiddlewareAllowsRequestsWithinBurst(t *testing.T) {
+	rl := NewRateLimiter(testRateLimitConfig(), logger.New(zap.FatalLevel))
+	defer rl.Close()
+
+	for i := 0; i < 2; i++ {
+		if w := serveRateLimited(rl, RouteClassCheap, "1.2.3.4"); w.Code != http.StatusOK {
+			t.Errorf("request %d: got status %d, expected %d", i, w.Code, http.StatusOK)
+		}
+	}
+}
+
+func TestMiddlewareRejectsOverBurstWithRetryAfter(t *testing.T) {
+	rl := NewRateLimiter(testRateLimitConfig(), logger.New(zap.FatalLevel))
+	defer rl.Close()
+
+	for i := 0; i < 2; i++ {
+		serveRateLimited(rl, RouteClassCheap, "1.2.3.4")
+	}
+
+	w := serveRateLimited(rl, RouteClassCheap, "1.2.3.4")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("got status %d, expected %d", w.Code, http.StatusTooManyRequests)
+	}
+
+	retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+	if err != nil || retryAfter <= 0 {
+		t.Errorf("expected a positive Retry-After header, got %#v", w.Header().Get("Retry-After"))
+	}
+}
+
+func TestMiddlewareSeparatesClientsByAddress(t *testing.T) {
+	rl := NewRateLimiter(testRateLimitConfig(), logger.New(zap.FatalLevel))
+	defer rl.Close()
+
+	for i := 0; i < 2; i++ {
+		serveRateLimited(rl, RouteClassCheap, "1.2.3.4")
+	}
+
+	if w := serveRateLimited(rl, RouteClassCheap, "5.6.7.8"); w.Code != http.StatusOK {
+		t.Errorf("a different client was rejected by another client's exhausted bucket, got status %d", w.Code)
+	}
+}
+
+func TestMiddlewareSeparatesRouteClasses(t *testing.T) {
+	rl := NewRateLimiter(testRateLimitConfig(), logger.New(zap.FatalLevel))
+	defer rl.Close()
+
+	for i := 0; i < 2; i++ {
+		serveRateLimited(rl, RouteClassCheap, "1.2.3.4")
+	}
+
+	if w := serveRateLimited(rl, RouteClassExpensive, "1.2.3.4"); w.Code != http.StatusOK {
+		t.Errorf("the expensive bucket was starved by the cheap bucket's quota, got status %d", w.Code)
+	}
+}
+
+func TestClientAddrPrefersForwardedFor(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "203.0.113.9, 10.0.0.2")
+
+	if got := clientAddr(req); got != "203.0.113.9" {
+		t.Errorf("got %#v, expected %#v", got, "203.0.113.9")
+	}
+}
+
+func TestClientAddrFallsBackToRemoteAddr(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+
+	if got := clientAddr(req); got != "10.0.0.1" {
+		t.Errorf("got %#v, expected %#v", got, "10.0.0.1")
+	}
+}
+
+func TestClassifyBySize(t *testing.T) {
+	classify := ClassifyBySize(100 * 100)
+
+	tests := []struct {
+		Name     string
+		URL      string
+		Expected RouteClass
+	}{
+		{"no dimensions", "/id/1/info", RouteClassCheap},
+		{"small", "/?width=50&height=50", RouteClassCheap},
+		{"at the boundary", "/?width=100&height=100", RouteClassCheap},
+		{"large", "/?width=2000&height=2000", RouteClassExpensive},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, test.URL, nil)
+		if got := classify(req); got != test.Expected {
+			t.Errorf("%s: got %#v, expected %#v", test.Name, got, test.Expected)
+		}
+	}
+}
+
+func TestEvictRemovesIdleClients(t *testing.T) {
+	rl := NewRateLimiter(testRateLimitConfig(), logger.New(zap.FatalLevel))
+	defer rl.Close()
+
+	key := clientKey{addr: "1.2.3.4", class: RouteClassCheap}
+	rl.limiterFor(key)
+
+	rl.mtx.Lock()
+	rl.clients[key].lastSeen = time.Now().Add(-2 * rl.cfg.EvictAfter)
+	rl.mtx.Unlock()
+
+	rl.evict()
+
+	rl.mtx.Lock()
+	_, stillPresent := rl.clients[key]
+	rl.mtx.Unlock()
+
+	if stillPresent {
+		t.Errorf("idle client was not evicted")
+	}
+}
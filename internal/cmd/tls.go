@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"crypto/tls"
+	"net/http"
+
+	"golang.org/x/crypto/acme/autocert"
+	"golang.org/x/net/http2"
+)
+
+// TLSConfig configures the optional HTTPS listener ListenAndServe runs
+// alongside the plain HTTP one.
+type TLSConfig struct {
+	// CertFile/KeyFile configure a static certificate pair. Leave both empty
+	// to use ACMEDomains instead.
+	CertFile string
+	KeyFile  string
+
+	// ACMEDomains, when CertFile/KeyFile aren't set, enables
+	// golang.org/x/crypto/acme/autocert for exactly these hostnames.
+	ACMEDomains []string
+
+	// ACMECacheDir is where autocert persists issued certificates, used when
+	// ACMECache is nil. Defaults to "certs" if empty.
+	ACMECacheDir string
+
+	// ACMECache overrides ACMECacheDir with a custom cache, e.g. one backed
+	// by this service's own object storage instead of local disk.
+	ACMECache autocert.Cache
+}
+
+// enabled reports whether cfg configures either a static certificate or ACME.
+func (cfg TLSConfig) enabled() bool {
+	return (cfg.CertFile != "" && cfg.KeyFile != "") || len(cfg.ACMEDomains) > 0
+}
+
+// modernCipherSuites are the ECDHE/ECDSA-preferred suites from Mozilla's
+// "modern" TLS compatibility profile. TLS 1.3's suites are fixed by the
+// runtime and aren't affected by this list.
+var modernCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+}
+
+// buildTLSConfig returns a *tls.Config enforcing TLS 1.2+ and
+// modernCipherSuites, serving a static certificate pair if cfg sets one, or
+// autocert-managed certificates for cfg.ACMEDomains otherwise. The returned
+// *autocert.Manager is non-nil only in the ACME case, so ListenAndServe can
+// wrap the plain HTTP listener with its HTTP-01 challenge handler.
+func buildTLSConfig(cfg TLSConfig) (*tls.Config, *autocert.Manager, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:       tls.VersionTLS12,
+		CipherSuites:     modernCipherSuites,
+		CurvePreferences: []tls.CurveID{tls.X25519, tls.CurveP256},
+	}
+
+	if cfg.CertFile != "" && cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+		return tlsConfig, nil, nil
+	}
+
+	cache := cfg.ACMECache
+	if cache == nil {
+		dir := cfg.ACMECacheDir
+		if dir == "" {
+			dir = "certs"
+		}
+		cache = autocert.DirCache(dir)
+	}
+
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(cfg.ACMEDomains...),
+		Cache:      cache,
+	}
+
+	// manager.TLSConfig() (rather than setting GetCertificate directly) adds
+	// "acme-tls/1" to NextProtos, which TLS-ALPN-01 challenge validation
+	// requires per RFC 8737.
+	acmeConfig := manager.TLSConfig()
+	tlsConfig.GetCertificate = acmeConfig.GetCertificate
+	tlsConfig.NextProtos = acmeConfig.NextProtos
+
+	return tlsConfig, manager, nil
+}
+
+// ListenAndServe runs an HTTP server on addr and, if tlsCfg configures a
+// static certificate or ACME domains, an HTTPS server on tlsAddr alongside
+// it, both serving handler with the package's ReadTimeout/WriteTimeout. It
+// blocks until either listener returns, and returns that error.
+func ListenAndServe(addr, tlsAddr string, handler http.Handler, tlsCfg TLSConfig) error {
+	errs := make(chan error, 2)
+
+	httpHandler := handler
+
+	if tlsCfg.enabled() {
+		tlsConfig, manager, err := buildTLSConfig(tlsCfg)
+		if err != nil {
+			return err
+		}
+
+		// manager is only set up for the ACME path; wrapping the plain HTTP
+		// handler with it serves the HTTP-01 challenge at
+		// /.well-known/acme-challenge/ and otherwise falls through to handler.
+		if manager != nil {
+			httpHandler = manager.HTTPHandler(handler)
+		}
+
+		httpsServer := &http.Server{
+			Addr:         tlsAddr,
+			Handler:      handler,
+			ReadTimeout:  ReadTimeout,
+			WriteTimeout: WriteTimeout,
+			TLSConfig:    tlsConfig,
+		}
+
+		if err := http2.ConfigureServer(httpsServer, &http2.Server{}); err != nil {
+			return err
+		}
+
+		go func() { errs <- httpsServer.ListenAndServeTLS("", "") }()
+	}
+
+	httpServer := &http.Server{
+		Addr:         addr,
+		Handler:      httpHandler,
+		ReadTimeout:  ReadTimeout,
+		WriteTimeout: WriteTimeout,
+	}
+	go func() { errs <- httpServer.ListenAndServe() }()
+
+	return <-errs
+}
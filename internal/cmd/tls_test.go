@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeSelfSignedCert generates an ephemeral ECDSA certificate/key pair for
+// tests, so buildTLSConfig can be exercised without a real CA.
+func writeSelfSignedCert(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error generating key: %s", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("unexpected error creating certificate: %s", err)
+	}
+
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("unexpected error marshaling key: %s", err)
+	}
+
+	dir := t.TempDir()
+
+	certFile = filepath.Join(dir, "cert.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("unexpected error writing cert: %s", err)
+	}
+
+	keyFile = filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes}), 0o600); err != nil {
+		t.Fatalf("unexpected error writing key: %s", err)
+	}
+
+	return certFile, keyFile
+}
+
+func TestBuildTLSConfigStaticCertificate(t *testing.T) {
+	certFile, keyFile := writeSelfSignedCert(t)
+
+	tlsConfig, manager, err := buildTLSConfig(TLSConfig{CertFile: certFile, KeyFile: keyFile})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if tlsConfig.MinVersion != tls.VersionTLS12 {
+		t.Errorf("got MinVersion %#v, expected TLS 1.2", tlsConfig.MinVersion)
+	}
+
+	if len(tlsConfig.Certificates) != 1 {
+		t.Fatalf("got %d certificates, expected 1", len(tlsConfig.Certificates))
+	}
+
+	if tlsConfig.GetCertificate != nil {
+		t.Errorf("GetCertificate set for a static certificate config, expected nil")
+	}
+
+	if manager != nil {
+		t.Errorf("got a non-nil autocert.Manager for a static certificate config, expected nil")
+	}
+}
+
+func TestBuildTLSConfigStaticCertificateInvalidFiles(t *testing.T) {
+	if _, _, err := buildTLSConfig(TLSConfig{CertFile: "/nonexistent/cert.pem", KeyFile: "/nonexistent/key.pem"}); err == nil {
+		t.Errorf("expected an error loading a nonexistent certificate pair")
+	}
+}
+
+func TestBuildTLSConfigACME(t *testing.T) {
+	tlsConfig, manager, err := buildTLSConfig(TLSConfig{
+		ACMEDomains:  []string{"example.com"},
+		ACMECacheDir: t.TempDir(),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if tlsConfig.GetCertificate == nil {
+		t.Errorf("GetCertificate not set for an ACME config")
+	}
+
+	if len(tlsConfig.Certificates) != 0 {
+		t.Errorf("static certificates set for an ACME config")
+	}
+
+	if manager == nil {
+		t.Fatalf("expected a non-nil autocert.Manager for an ACME config")
+	}
+
+	foundALPN := false
+	for _, proto := range tlsConfig.NextProtos {
+		if proto == "acme-tls/1" {
+			foundALPN = true
+		}
+	}
+	if !foundALPN {
+		t.Errorf("got NextProtos %#v, expected it to include acme-tls/1 for TLS-ALPN-01", tlsConfig.NextProtos)
+	}
+}
+
+func TestTLSConfigEnabled(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Config   TLSConfig
+		Expected bool
+	}{
+		{"neither set", TLSConfig{}, false},
+		{"static certificate", TLSConfig{CertFile: "a", KeyFile: "b"}, true},
+		{"acme domains", TLSConfig{ACMEDomains: []string{"example.com"}}, true},
+		{"cert file without key", TLSConfig{CertFile: "a"}, false},
+	}
+
+	for _, test := range tests {
+		if got := test.Config.enabled(); got != test.Expected {
+			t.Errorf("%s: got %#v, expected %#v", test.Name, got, test.Expected)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+// Package database defines the interface used to look up image metadata.
+package database
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotFound is returned when the requested image does not exist.
+var ErrNotFound = errors.New("image does not exist")
+
+// Image represents a single image's metadata.
+type Image struct {
+	ID     string `json:"id"`
+	Author string `json:"author"`
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// QueryOptions describes the filtering, sorting and pagination applied by Query.
+type QueryOptions struct {
+	Page   int
+	Limit  int
+	Author string
+
+	// Orientation filters on "landscape", "portrait" or "square". Empty means no filter.
+	Orientation string
+	MinWidth    int
+	MinHeight   int
+	MaxWidth    int
+	MaxHeight   int
+
+	// Sort is one of "id", "width", "height" or "author". Defaults to "id".
+	Sort string
+	// Order is "asc" or "desc". Defaults to "asc".
+	Order string
+}
+
+// Provider is the interface implemented by the different metadata backends.
+type Provider interface {
+	Get(ctx context.Context, id string) (Image, error)
+	GetRandom(ctx context.Context) (string, error)
+	GetRandomWithSeed(ctx context.Context, seed int64) (string, error)
+	List(ctx context.Context, page int, limit int) ([]Image, error)
+	Query(ctx context.Context, opts QueryOptions) ([]Image, error)
+	Count(ctx context.Context) (int, error)
+}
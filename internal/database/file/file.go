@@ -0,0 +1,184 @@
+// Package file implements the database.Provider interface backed by a static JSON file.
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/DMarby/picsum-photos/internal/database"
+)
+
+// Provider is a file-backed implementation of database.Provider.
+type Provider struct {
+	images []database.Image
+}
+
+// New returns a new file-backed provider, loading metadata from path.
+func New(path string) (*Provider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var images []database.Image
+	if err := json.Unmarshal(data, &images); err != nil {
+		return nil, err
+	}
+
+	return &Provider{images: images}, nil
+}
+
+// Get returns the image with the given id.
+func (p *Provider) Get(ctx context.Context, id string) (database.Image, error) {
+	for _, image := range p.images {
+		if image.ID == id {
+			return image, nil
+		}
+	}
+
+	return database.Image{}, database.ErrNotFound
+}
+
+// GetRandom returns the id of a random image.
+func (p *Provider) GetRandom(ctx context.Context) (string, error) {
+	if len(p.images) == 0 {
+		return "", database.ErrNotFound
+	}
+
+	return p.images[rand.Intn(len(p.images))].ID, nil
+}
+
+// GetRandomWithSeed returns the id of a random image, deterministic for a given seed.
+func (p *Provider) GetRandomWithSeed(ctx context.Context, seed int64) (string, error) {
+	if len(p.images) == 0 {
+		return "", database.ErrNotFound
+	}
+
+	r := rand.New(rand.NewSource(seed))
+	return p.images[r.Intn(len(p.images))].ID, nil
+}
+
+// List returns a page of images.
+func (p *Provider) List(ctx context.Context, page int, limit int) ([]database.Image, error) {
+	offset := (page - 1) * limit
+	if offset >= len(p.images) {
+		return []database.Image{}, nil
+	}
+
+	end := offset + limit
+	if end > len(p.images) {
+		end = len(p.images)
+	}
+
+	return p.images[offset:end], nil
+}
+
+// Count returns the total number of images.
+func (p *Provider) Count(ctx context.Context) (int, error) {
+	return len(p.images), nil
+}
+
+// Query returns a filtered, sorted and paginated slice of images.
+func (p *Provider) Query(ctx context.Context, opts database.QueryOptions) ([]database.Image, error) {
+	filtered := make([]database.Image, 0, len(p.images))
+	for _, image := range p.images {
+		if matchesQuery(image, opts) {
+			filtered = append(filtered, image)
+		}
+	}
+
+	sortImages(filtered, opts.Sort, opts.Order)
+
+	page := opts.Page
+	if page < 1 {
+		page = 1
+	}
+	limit := opts.Limit
+
+	offset := (page - 1) * limit
+	if offset >= len(filtered) {
+		return []database.Image{}, nil
+	}
+
+	end := offset + limit
+	if end > len(filtered) {
+		end = len(filtered)
+	}
+
+	return filtered[offset:end], nil
+}
+
+func matchesQuery(image database.Image, opts database.QueryOptions) bool {
+	if opts.Author != "" && image.Author != opts.Author {
+		return false
+	}
+
+	if opts.Orientation != "" && orientation(image) != opts.Orientation {
+		return false
+	}
+
+	if opts.MinWidth != 0 && image.Width < opts.MinWidth {
+		return false
+	}
+	if opts.MinHeight != 0 && image.Height < opts.MinHeight {
+		return false
+	}
+	if opts.MaxWidth != 0 && image.Width > opts.MaxWidth {
+		return false
+	}
+	if opts.MaxHeight != 0 && image.Height > opts.MaxHeight {
+		return false
+	}
+
+	return true
+}
+
+func orientation(image database.Image) string {
+	switch {
+	case image.Width > image.Height:
+		return "landscape"
+	case image.Height > image.Width:
+		return "portrait"
+	default:
+		return "square"
+	}
+}
+
+func sortImages(images []database.Image, field string, order string) {
+	less := func(i, j int) bool {
+		switch field {
+		case "width":
+			return images[i].Width < images[j].Width
+		case "height":
+			return images[i].Height < images[j].Height
+		case "author":
+			return images[i].Author < images[j].Author
+		default:
+			return lessID(images[i].ID, images[j].ID)
+		}
+	}
+
+	if order == "desc" {
+		sort.SliceStable(images, func(i, j int) bool { return less(j, i) })
+		return
+	}
+
+	sort.SliceStable(images, less)
+}
+
+// lessID orders ids numerically where both parse as integers (the common
+// case, since ids are assigned sequentially), falling back to a lexicographic
+// compare so non-numeric ids still sort deterministically.
+func lessID(a, b string) bool {
+	aNum, aErr := strconv.Atoi(a)
+	bNum, bErr := strconv.Atoi(b)
+	if aErr == nil && bErr == nil {
+		return aNum < bNum
+	}
+
+	return a < b
+}
@@ -0,0 +1,123 @@
+package file
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DMarby/picsum-photos/internal/database"
+)
+
+func TestQuerySortByIDIsNumeric(t *testing.T) {
+	p := &Provider{
+		images: []database.Image{
+			{ID: "10"},
+			{ID: "2"},
+			{ID: "1"},
+		},
+	}
+
+	images, err := p.Query(context.Background(), database.QueryOptions{Page: 1, Limit: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []string{"1", "2", "10"}
+	for i, image := range images {
+		if image.ID != expected[i] {
+			t.Errorf("wrong order at index %d, got %#v, expected %#v", i, image.ID, expected[i])
+		}
+	}
+}
+
+func testQueryProvider() *Provider {
+	return &Provider{
+		images: []database.Image{
+			{ID: "1", Author: "Alice", Width: 800, Height: 600},
+			{ID: "2", Author: "Bob", Width: 400, Height: 300},
+			{ID: "3", Author: "Alice", Width: 1600, Height: 900},
+		},
+	}
+}
+
+func TestQuerySortByWidth(t *testing.T) {
+	p := testQueryProvider()
+
+	images, err := p.Query(context.Background(), database.QueryOptions{Page: 1, Limit: 10, Sort: "width"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []string{"2", "1", "3"}
+	for i, image := range images {
+		if image.ID != expected[i] {
+			t.Errorf("wrong order at index %d, got %#v, expected %#v", i, image.ID, expected[i])
+		}
+	}
+}
+
+func TestQuerySortByAuthor(t *testing.T) {
+	p := testQueryProvider()
+
+	images, err := p.Query(context.Background(), database.QueryOptions{Page: 1, Limit: 10, Sort: "author"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []string{"1", "3", "2"}
+	for i, image := range images {
+		if image.ID != expected[i] {
+			t.Errorf("wrong order at index %d, got %#v, expected %#v", i, image.ID, expected[i])
+		}
+	}
+}
+
+func TestQueryFiltersByAuthor(t *testing.T) {
+	p := testQueryProvider()
+
+	images, err := p.Query(context.Background(), database.QueryOptions{Page: 1, Limit: 10, Author: "Alice"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []string{"1", "3"}
+	if len(images) != len(expected) {
+		t.Fatalf("got %d images, expected %d", len(images), len(expected))
+	}
+	for i, image := range images {
+		if image.ID != expected[i] {
+			t.Errorf("wrong order at index %d, got %#v, expected %#v", i, image.ID, expected[i])
+		}
+	}
+}
+
+func TestQueryFiltersByNonMatchingAuthorFindsNothing(t *testing.T) {
+	p := testQueryProvider()
+
+	images, err := p.Query(context.Background(), database.QueryOptions{Page: 1, Limit: 10, Author: "Carol"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(images) != 0 {
+		t.Errorf("got %d images, expected none", len(images))
+	}
+}
+
+func TestQueryFiltersByDimensions(t *testing.T) {
+	p := testQueryProvider()
+
+	images, err := p.Query(context.Background(), database.QueryOptions{Page: 1, Limit: 10, MinWidth: 500, MaxHeight: 700})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []string{"1"}
+	if len(images) != len(expected) {
+		t.Fatalf("got %d images, expected %d", len(images), len(expected))
+	}
+	for i, image := range images {
+		if image.ID != expected[i] {
+			t.Errorf("wrong order at index %d, got %#v, expected %#v", i, image.ID, expected[i])
+		}
+	}
+}
@@ -0,0 +1,44 @@
+// Package mock implements a database.Provider that always returns an error, for testing.
+package mock
+
+import (
+	"context"
+	"errors"
+
+	"github.com/DMarby/picsum-photos/internal/database"
+)
+
+var errMock = errors.New("something went wrong")
+
+// Provider is a database.Provider that always returns errMock.
+type Provider struct{}
+
+// Get always returns an error.
+func (p *Provider) Get(ctx context.Context, id string) (database.Image, error) {
+	return database.Image{}, errMock
+}
+
+// GetRandom always returns an error.
+func (p *Provider) GetRandom(ctx context.Context) (string, error) {
+	return "", errMock
+}
+
+// GetRandomWithSeed always returns an error.
+func (p *Provider) GetRandomWithSeed(ctx context.Context, seed int64) (string, error) {
+	return "", errMock
+}
+
+// List always returns an error.
+func (p *Provider) List(ctx context.Context, page int, limit int) ([]database.Image, error) {
+	return nil, errMock
+}
+
+// Query always returns an error.
+func (p *Provider) Query(ctx context.Context, opts database.QueryOptions) ([]database.Image, error) {
+	return nil, errMock
+}
+
+// Count always returns an error.
+func (p *Provider) Count(ctx context.Context) (int, error) {
+	return 0, errMock
+}
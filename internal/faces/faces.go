@@ -0,0 +1,144 @@
+// Package faces detects and caches faces in the storage-backed original images,
+// so that image requests can crop around them instead of using a plain center-crop.
+package faces
+
+import (
+	"bytes"
+	"context"
+	"image"
+	_ "image/jpeg"
+
+	"github.com/DMarby/picsum-photos/internal/lru"
+	"github.com/DMarby/picsum-photos/internal/storage"
+)
+
+// maxCacheEntries bounds the number of detected-faces results kept in
+// memory. The key space is normally bounded by the image catalog, but cap it
+// anyway so a catalog of unexpected size can't grow the cache without bound;
+// the least-recently-used entry is evicted once the cap is reached.
+const maxCacheEntries = 100000
+
+// Face is a detected face's bounding box and detector confidence score.
+type Face struct {
+	X     int     `json:"x"`
+	Y     int     `json:"y"`
+	W     int     `json:"w"`
+	H     int     `json:"h"`
+	Score float32 `json:"score"`
+}
+
+// Thresholds tunes which detector candidates are kept.
+type Thresholds struct {
+	// MinSizeRatio is the minimum face size, relative to the image's shortest side.
+	MinSizeRatio float32
+	// MinScore is the minimum detector confidence required to keep a candidate face.
+	MinScore float32
+}
+
+// DefaultThresholds are the thresholds used when none are supplied, similar to the
+// defaults used by other photo management services.
+var DefaultThresholds = Thresholds{MinSizeRatio: 0.05, MinScore: 5.0}
+
+// Detector finds raw candidate faces in a decoded image, before thresholding.
+type Detector interface {
+	Detect(img image.Image) []Face
+}
+
+// Service detects and caches faces for storage-backed original images, keyed
+// by image id. It keeps at most maxCacheEntries entries, evicting the
+// least-recently-used one as new results are cached.
+type Service struct {
+	Storage    storage.Provider
+	Detector   Detector
+	Thresholds Thresholds
+
+	cache *lru.Cache[string, []Face]
+}
+
+// New returns a new Service backed by the given storage provider and detector.
+func New(s storage.Provider, d Detector, thresholds Thresholds) *Service {
+	return &Service{
+		Storage:    s,
+		Detector:   d,
+		Thresholds: thresholds,
+		cache:      lru.New[string, []Face](maxCacheEntries),
+	}
+}
+
+// Get returns the faces detected in the image with the given id, stored at path,
+// filtered by the configured thresholds. Results are cached by id.
+func (s *Service) Get(ctx context.Context, id string, path string) ([]Face, error) {
+	if cached, ok := s.cache.Get(id); ok {
+		return cached, nil
+	}
+
+	data, err := s.Storage.Get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := s.Detector.Detect(img)
+	result := s.threshold(img, candidates)
+
+	s.cache.Store(id, result)
+
+	return result, nil
+}
+
+func (s *Service) threshold(img image.Image, candidates []Face) []Face {
+	bounds := img.Bounds()
+	shortestSide := bounds.Dx()
+	if bounds.Dy() < shortestSide {
+		shortestSide = bounds.Dy()
+	}
+	minSize := int(s.Thresholds.MinSizeRatio * float32(shortestSide))
+
+	kept := make([]Face, 0, len(candidates))
+	for _, face := range candidates {
+		if face.Score < s.Thresholds.MinScore {
+			continue
+		}
+		if face.W < minSize || face.H < minSize {
+			continue
+		}
+		kept = append(kept, face)
+	}
+
+	return kept
+}
+
+// Largest returns the highest-scoring face, if any were detected.
+func Largest(faces []Face) (Face, bool) {
+	if len(faces) == 0 {
+		return Face{}, false
+	}
+
+	largest := faces[0]
+	for _, face := range faces[1:] {
+		if face.W*face.H > largest.W*largest.H {
+			largest = face
+		}
+	}
+
+	return largest, true
+}
+
+// Centroid returns the bounding-box centroid of all detected faces, if any.
+func Centroid(faces []Face) (x int, y int, ok bool) {
+	if len(faces) == 0 {
+		return 0, 0, false
+	}
+
+	var sumX, sumY int
+	for _, face := range faces {
+		sumX += face.X + face.W/2
+		sumY += face.Y + face.H/2
+	}
+
+	return sumX / len(faces), sumY / len(faces), true
+}
@@ -0,0 +1,20 @@
+package faces
+
+import (
+	"testing"
+)
+
+func TestCacheHitAvoidsStorage(t *testing.T) {
+	s := New(nil, nil, DefaultThresholds)
+
+	s.cache.Store("1", []Face{{X: 1}})
+
+	result, ok := s.cache.Get("1")
+	if !ok {
+		t.Fatalf("expected the cached result to be found")
+	}
+
+	if len(result) != 1 || result[0].X != 1 {
+		t.Errorf("got %#v, expected the cached result", result)
+	}
+}
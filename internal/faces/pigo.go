@@ -0,0 +1,65 @@
+package faces
+
+import (
+	"image"
+	"os"
+
+	pigo "github.com/esimov/pigo/core"
+)
+
+// pigoDetector wraps a Pigo cascade classifier as a Detector.
+type pigoDetector struct {
+	classifier *pigo.Pigo
+}
+
+// NewPigoDetector loads the Pigo cascade file at path and returns a Detector backed by it.
+func NewPigoDetector(cascadeFile string) (Detector, error) {
+	raw, err := os.ReadFile(cascadeFile)
+	if err != nil {
+		return nil, err
+	}
+
+	classifier := pigo.NewPigo()
+
+	cascade, err := classifier.Unpack(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pigoDetector{classifier: cascade}, nil
+}
+
+// Detect runs the Pigo cascade classifier over img and returns the raw candidates.
+func (d *pigoDetector) Detect(img image.Image) []Face {
+	grayscale := pigo.RgbToGrayscale(img)
+	bounds := img.Bounds()
+
+	params := pigo.CascadeParams{
+		MinSize:     20,
+		MaxSize:     1000,
+		ShiftFactor: 0.1,
+		ScaleFactor: 1.1,
+		ImageParams: pigo.ImageParams{
+			Pixels: grayscale,
+			Rows:   bounds.Dy(),
+			Cols:   bounds.Dx(),
+			Dim:    bounds.Dx(),
+		},
+	}
+
+	candidates := d.classifier.RunCascade(params, 0)
+	candidates = d.classifier.ClusterDetections(candidates, 0.2)
+
+	faces := make([]Face, 0, len(candidates))
+	for _, c := range candidates {
+		faces = append(faces, Face{
+			X:     c.Col - c.Scale/2,
+			Y:     c.Row - c.Scale/2,
+			W:     c.Scale,
+			H:     c.Scale,
+			Score: float32(c.Q),
+		})
+	}
+
+	return faces
+}
@@ -0,0 +1,64 @@
+// Package fingerprint computes stable content fingerprints for fully-resolved
+// image requests, so that equivalent requests differing only in parameter
+// order resolve to the same cache-friendly URL.
+package fingerprint
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+
+	"github.com/DMarby/picsum-photos/internal/lru"
+)
+
+// length is the number of hex characters of the sha-256 sum used as the
+// short, URL-safe fingerprint.
+const length = 16
+
+// maxTargets bounds the number of registered targets kept in memory. Register
+// is called on every cacheable redirect, so without a cap the map would grow
+// without bound under sustained traffic; the least-recently-used entry is
+// evicted once the cap is reached.
+const maxTargets = 100000
+
+// Service registers canonicalized targets and resolves fingerprints back to
+// them, so a content-addressable URL can be redirected without re-deriving
+// its parameters. It keeps at most maxTargets entries, evicting the
+// least-recently-used one as new targets are registered.
+type Service struct {
+	targets *lru.Cache[string, string]
+}
+
+// New returns a new, empty Service.
+func New() *Service {
+	return &Service{
+		targets: lru.New[string, string](maxTargets),
+	}
+}
+
+// Register computes the fingerprint for target and remembers the mapping so
+// it can later be resolved with Lookup. target should be the canonicalized
+// parameter tuple (path plus query string) a redirect would otherwise sign,
+// not the rendered image bytes.
+func (s *Service) Register(target string) string {
+	fingerprint := hex.EncodeToString(sum(target))[:length]
+
+	s.targets.Store(fingerprint, target)
+
+	return fingerprint
+}
+
+// Lookup returns the target previously registered for fingerprint, if any.
+func (s *Service) Lookup(fingerprint string) (string, bool) {
+	return s.targets.Get(fingerprint)
+}
+
+// Digest returns the RFC 3230-style `sha-256=<base64>` digest value for target.
+func Digest(target string) string {
+	return "sha-256=" + base64.StdEncoding.EncodeToString(sum(target))
+}
+
+func sum(target string) []byte {
+	sum := sha256.Sum256([]byte(target))
+	return sum[:]
+}
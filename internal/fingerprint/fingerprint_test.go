@@ -0,0 +1,69 @@
+package fingerprint
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestRegisterLookupRoundTrip(t *testing.T) {
+	s := New()
+
+	fingerprint := s.Register("/id/1/200/300.jpg")
+
+	target, ok := s.Lookup(fingerprint)
+	if !ok {
+		t.Fatalf("expected lookup to succeed")
+	}
+
+	if target != "/id/1/200/300.jpg" {
+		t.Errorf("got %#v, expected the registered target", target)
+	}
+}
+
+func TestLookupUnknownFingerprint(t *testing.T) {
+	s := New()
+
+	if _, ok := s.Lookup("0000000000000000"); ok {
+		t.Errorf("expected lookup of an unregistered fingerprint to fail")
+	}
+}
+
+func TestRegisterEvictsLeastRecentlyUsed(t *testing.T) {
+	s := New()
+
+	first := s.Register("/id/1/200/300.jpg")
+
+	for i := 0; i < maxTargets; i++ {
+		s.Register(targetFor(i))
+	}
+
+	if _, ok := s.Lookup(first); ok {
+		t.Errorf("expected the least-recently-used entry to have been evicted")
+	}
+}
+
+func TestLookupRefreshesRecency(t *testing.T) {
+	s := New()
+
+	kept := s.Register("/id/1/200/300.jpg")
+
+	for i := 0; i < maxTargets-1; i++ {
+		s.Register(targetFor(i))
+	}
+
+	if _, ok := s.Lookup(kept); !ok {
+		t.Fatalf("expected kept entry to still be present")
+	}
+
+	for i := maxTargets - 1; i < maxTargets; i++ {
+		s.Register(targetFor(i))
+	}
+
+	if _, ok := s.Lookup(kept); !ok {
+		t.Errorf("expected a recently looked-up entry to survive eviction")
+	}
+}
+
+func targetFor(i int) string {
+	return "/id/1/200/300.jpg?n=" + strconv.Itoa(i)
+}
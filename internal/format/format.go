@@ -0,0 +1,87 @@
+// Package format negotiates the output image format for a request, either
+// from an explicit URL extension or by negotiating against the Accept
+// header. Codecs are kept in a registry so new ones can be added without
+// changing the negotiation logic, or the HTTP layer that calls it.
+package format
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Format describes a registered output format.
+type Format struct {
+	Extension string
+	MIMEType  string
+}
+
+// Default is returned by Negotiate when nothing else matches.
+var Default = Format{Extension: ".jpg", MIMEType: "image/jpeg"}
+
+// Registry holds the set of output formats the service can produce.
+type Registry struct {
+	byExt  map[string]Format
+	byMIME map[string]Format
+}
+
+// New returns a Registry seeded with the service's built-in formats.
+func New() *Registry {
+	r := &Registry{byExt: map[string]Format{}, byMIME: map[string]Format{}}
+
+	r.Register(Default)
+	r.Register(Format{Extension: ".webp", MIMEType: "image/webp"})
+	r.Register(Format{Extension: ".avif", MIMEType: "image/avif"})
+
+	return r
+}
+
+// Register adds a codec to the registry, making it selectable by explicit
+// URL extension or Accept-header negotiation.
+func (r *Registry) Register(f Format) {
+	r.byExt[f.Extension] = f
+	r.byMIME[f.MIMEType] = f
+}
+
+// Lookup returns the registered format for an explicit URL extension.
+func (r *Registry) Lookup(ext string) (Format, bool) {
+	f, ok := r.byExt[ext]
+	return f, ok
+}
+
+// Negotiate parses an Accept header and returns the highest-quality
+// registered format the client advertises, falling back to Default if the
+// header is empty or matches nothing registered.
+func (r *Registry) Negotiate(accept string) Format {
+	best := Default
+	bestQ := -1.0
+
+	for _, part := range strings.Split(accept, ",") {
+		mime, q := parseAccept(part)
+		if f, ok := r.byMIME[mime]; ok && q > bestQ {
+			best, bestQ = f, q
+		}
+	}
+
+	return best
+}
+
+// parseAccept splits a single Accept header entry into its media type and
+// `q` weight, defaulting to a weight of 1 when absent or invalid.
+func parseAccept(part string) (mime string, q float64) {
+	q = 1
+
+	fields := strings.Split(part, ";")
+	mime = strings.TrimSpace(fields[0])
+
+	for _, param := range fields[1:] {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok || key != "q" {
+			continue
+		}
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			q = parsed
+		}
+	}
+
+	return mime, q
+}
@@ -0,0 +1,49 @@
+package format
+
+import "testing"
+
+func TestRegistryLookup(t *testing.T) {
+	r := New()
+
+	tests := []struct {
+		Ext      string
+		Expected Format
+		Ok       bool
+	}{
+		{".jpg", Format{".jpg", "image/jpeg"}, true},
+		{".webp", Format{".webp", "image/webp"}, true},
+		{".avif", Format{".avif", "image/avif"}, true},
+		{".gif", Format{}, false},
+	}
+
+	for _, test := range tests {
+		f, ok := r.Lookup(test.Ext)
+		if ok != test.Ok || f != test.Expected {
+			t.Errorf("Lookup(%q) = %#v, %v, expected %#v, %v", test.Ext, f, ok, test.Expected, test.Ok)
+		}
+	}
+}
+
+func TestRegistryNegotiate(t *testing.T) {
+	r := New()
+
+	tests := []struct {
+		Name     string
+		Accept   string
+		Expected Format
+	}{
+		{"empty accept header", "", Default},
+		{"unsupported type only", "text/html", Default},
+		{"single supported type", "image/webp", Format{".webp", "image/webp"}},
+		{"highest explicit quality wins", "image/webp;q=0.8, image/avif;q=0.9", Format{".avif", "image/avif"}},
+		{"wildcard is ignored in favor of a concrete match", "*/*, image/avif;q=0.5", Format{".avif", "image/avif"}},
+		{"typical browser accept header", "image/avif,image/webp,image/apng,*/*;q=0.8", Format{".avif", "image/avif"}},
+	}
+
+	for _, test := range tests {
+		f := r.Negotiate(test.Accept)
+		if f != test.Expected {
+			t.Errorf("%s: Negotiate(%q) = %#v, expected %#v", test.Name, test.Accept, f, test.Expected)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+// Package hmac provides HMAC signing and validation of image service URLs.
+package hmac
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// HMAC holds the signing key used to sign and validate URLs.
+type HMAC struct {
+	Key []byte
+}
+
+// Create returns the hex-encoded HMAC-SHA256 signature of the given data.
+func (h *HMAC) Create(data string) (string, error) {
+	mac := hmac.New(sha256.New, h.Key)
+	if _, err := mac.Write([]byte(data)); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Validate returns true if signature is the correct HMAC-SHA256 signature for data.
+func (h *HMAC) Validate(data string, signature string) (bool, error) {
+	expected, err := h.Create(data)
+	if err != nil {
+		return false, err
+	}
+
+	return hmac.Equal([]byte(expected), []byte(signature)), nil
+}
@@ -0,0 +1,20 @@
+// Package logger provides a thin wrapper around zap for structured logging.
+package logger
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// New returns a new zap.Logger logging at the given level.
+func New(level zapcore.Level) *zap.Logger {
+	config := zap.NewProductionConfig()
+	config.Level = zap.NewAtomicLevelAt(level)
+
+	log, err := config.Build()
+	if err != nil {
+		panic(err)
+	}
+
+	return log
+}
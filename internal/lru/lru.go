@@ -0,0 +1,73 @@
+// Package lru implements a fixed-capacity, least-recently-used cache safe
+// for concurrent use.
+package lru
+
+import (
+	"container/list"
+	"sync"
+)
+
+// entry is the value stored in the eviction list, pairing the key with its
+// cached value so eviction can remove the matching map entry.
+type entry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// Cache is a fixed-capacity, least-recently-used cache safe for concurrent
+// use. It keeps at most max entries, evicting the least-recently-used one as
+// new entries are stored.
+type Cache[K comparable, V any] struct {
+	max int
+
+	mtx   sync.Mutex
+	items map[K]*list.Element
+	lru   *list.List
+}
+
+// New returns a new Cache holding at most max entries.
+func New[K comparable, V any](max int) *Cache[K, V] {
+	return &Cache[K, V]{
+		max:   max,
+		items: make(map[K]*list.Element),
+		lru:   list.New(),
+	}
+}
+
+// Get returns the cached value for key, if present, moving it to the front
+// of the eviction order.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+
+	c.lru.MoveToFront(el)
+	return el.Value.(entry[K, V]).value, true
+}
+
+// Store caches value under key, evicting the least-recently-used entry if
+// the cache is at capacity. Storing an already-present key just refreshes
+// its recency.
+func (c *Cache[K, V]) Store(key K, value V) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.lru.MoveToFront(el)
+		return
+	}
+
+	el := c.lru.PushFront(entry[K, V]{key: key, value: value})
+	c.items[key] = el
+
+	if c.lru.Len() > c.max {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.items, oldest.Value.(entry[K, V]).key)
+	}
+}
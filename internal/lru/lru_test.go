@@ -0,0 +1,73 @@
+package lru
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestGetStoreRoundTrip(t *testing.T) {
+	c := New[string, int](10)
+
+	c.Store("a", 1)
+
+	value, ok := c.Get("a")
+	if !ok {
+		t.Fatalf("expected lookup to succeed")
+	}
+
+	if value != 1 {
+		t.Errorf("got %#v, expected 1", value)
+	}
+}
+
+func TestGetUnknownKey(t *testing.T) {
+	c := New[string, int](10)
+
+	if _, ok := c.Get("missing"); ok {
+		t.Errorf("expected lookup of an unstored key to fail")
+	}
+}
+
+func TestEvictsLeastRecentlyUsed(t *testing.T) {
+	const max = 100
+
+	c := New[string, int](max)
+
+	c.Store("first", 1)
+
+	for i := 0; i < max; i++ {
+		c.Store(keyFor(i), i)
+	}
+
+	if _, ok := c.Get("first"); ok {
+		t.Errorf("expected the least-recently-used entry to have been evicted")
+	}
+}
+
+func TestGetRefreshesRecency(t *testing.T) {
+	const max = 100
+
+	c := New[string, int](max)
+
+	c.Store("kept", 1)
+
+	for i := 0; i < max-1; i++ {
+		c.Store(keyFor(i), i)
+	}
+
+	if _, ok := c.Get("kept"); !ok {
+		t.Fatalf("expected kept entry to still be present")
+	}
+
+	for i := max - 1; i < max; i++ {
+		c.Store(keyFor(i), i)
+	}
+
+	if _, ok := c.Get("kept"); !ok {
+		t.Errorf("expected a recently looked-up entry to survive eviction")
+	}
+}
+
+func keyFor(i int) string {
+	return "key-" + strconv.Itoa(i)
+}
@@ -0,0 +1,106 @@
+// Package metadata extracts and caches EXIF metadata for the original, stored images.
+package metadata
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/rwcarlsen/goexif/exif"
+
+	"github.com/DMarby/picsum-photos/internal/lru"
+	"github.com/DMarby/picsum-photos/internal/storage"
+)
+
+// maxCacheEntries bounds the number of decoded EXIF results kept in memory.
+// The key space is normally bounded by the image catalog, but cap it anyway
+// so a catalog of unexpected size can't grow the cache without bound; the
+// least-recently-used entry is evicted once the cap is reached.
+const maxCacheEntries = 100000
+
+// EXIF holds the parsed EXIF tags and derived attributes for an image, mirroring
+// the shape returned by other photo management services.
+type EXIF struct {
+	Camera      string   `json:"camera,omitempty"`
+	Lens        string   `json:"lens,omitempty"`
+	TakenAt     string   `json:"taken_at,omitempty"`
+	Latitude    *float64 `json:"latitude,omitempty"`
+	Longitude   *float64 `json:"longitude,omitempty"`
+	Orientation int      `json:"orientation,omitempty"`
+	FocalLength string   `json:"focal_length,omitempty"`
+}
+
+// Service parses EXIF data from storage-backed original images, caching
+// results by image id. It keeps at most maxCacheEntries entries, evicting
+// the least-recently-used one as new results are cached.
+type Service struct {
+	Storage storage.Provider
+
+	cache *lru.Cache[string, *EXIF]
+}
+
+// New returns a new Service backed by the given storage provider.
+func New(s storage.Provider) *Service {
+	return &Service{
+		Storage: s,
+		cache:   lru.New[string, *EXIF](maxCacheEntries),
+	}
+}
+
+// Get returns the parsed EXIF data for the image with the given id, stored at path.
+// Results are cached by id, so repeated calls are cheap.
+func (s *Service) Get(ctx context.Context, id string, path string) (*EXIF, error) {
+	if cached, ok := s.cache.Get(id); ok {
+		return cached, nil
+	}
+
+	data, err := s.Storage.Get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	x, err := exif.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("no exif data: %w", err)
+	}
+
+	result := fromExif(x)
+
+	s.cache.Store(id, result)
+
+	return result, nil
+}
+
+// fromExif maps the raw EXIF tags onto the derived EXIF shape.
+func fromExif(x *exif.Exif) *EXIF {
+	result := &EXIF{}
+
+	if camera, err := x.Get(exif.Model); err == nil {
+		result.Camera, _ = camera.StringVal()
+	}
+
+	if lens, err := x.Get(exif.LensModel); err == nil {
+		result.Lens, _ = lens.StringVal()
+	}
+
+	if taken, err := x.DateTime(); err == nil {
+		result.TakenAt = taken.Format("2006-01-02T15:04:05Z07:00")
+	}
+
+	if lat, lng, err := x.LatLong(); err == nil {
+		result.Latitude = &lat
+		result.Longitude = &lng
+	}
+
+	if orientation, err := x.Get(exif.Orientation); err == nil {
+		if value, err := orientation.Int(0); err == nil {
+			result.Orientation = value
+		}
+	}
+
+	if focalLength, err := x.Get(exif.FocalLength); err == nil {
+		result.FocalLength = focalLength.String()
+	}
+
+	return result
+}
@@ -0,0 +1,20 @@
+package metadata
+
+import (
+	"testing"
+)
+
+func TestCacheHitAvoidsStorage(t *testing.T) {
+	s := New(nil)
+
+	s.cache.Store("1", &EXIF{Camera: "cached"})
+
+	result, ok := s.cache.Get("1")
+	if !ok {
+		t.Fatalf("expected the cached result to be found")
+	}
+
+	if result.Camera != "cached" {
+		t.Errorf("got %#v, expected the cached result", result.Camera)
+	}
+}
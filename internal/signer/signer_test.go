@@ -0,0 +1,93 @@
+package signer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DMarby/picsum-photos/internal/hmac"
+)
+
+func TestSignWithExpiryRoundTrip(t *testing.T) {
+	h := &hmac.HMAC{Key: []byte("secret")}
+
+	signedTarget, signature, err := SignWithExpiry(h, "/id/1/200/200.jpg", time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := Validate(h, signedTarget, signature, false); err != nil {
+		t.Errorf("unexpected error validating a freshly signed target: %s", err)
+	}
+}
+
+func TestSignWithExpiryZeroTTLOmitsExpiry(t *testing.T) {
+	h := &hmac.HMAC{Key: []byte("secret")}
+
+	signedTarget, _, err := SignWithExpiry(h, "/id/1/200/200.jpg", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if signedTarget != "/id/1/200/200.jpg" {
+		t.Errorf("got %#v, expected target unmodified", signedTarget)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	h := &hmac.HMAC{Key: []byte("secret")}
+	other := &hmac.HMAC{Key: []byte("wrong-secret")}
+
+	unexpiredTarget, unexpiredSignature, err := SignWithExpiry(h, "/id/1/200/200.jpg", time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expiredTarget, expiredSignature, err := SignWithExpiry(h, "/id/1/200/200.jpg", -time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tamperedTarget, tamperedSignature, err := SignWithExpiry(h, "/id/1/200/200.jpg", time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	tamperedTarget += "0"
+
+	wrongSignature, err := other.Create("/id/1/200/200.jpg")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tests := []struct {
+		Name          string
+		Target        string
+		Signature     string
+		RequireExpiry bool
+		ExpectedErr   error
+	}{
+		{"valid-unexpired", unexpiredTarget, unexpiredSignature, false, nil},
+		{"valid-expired", expiredTarget, expiredSignature, false, ErrExpired},
+		{"tampered-exp", tamperedTarget, tamperedSignature, false, ErrInvalidSignature},
+		{"missing-exp-with-required-policy", "/id/1/200/200.jpg", mustCreate(t, h, "/id/1/200/200.jpg"), true, ErrMissingExpiry},
+		{"missing-exp-without-required-policy", "/id/1/200/200.jpg", mustCreate(t, h, "/id/1/200/200.jpg"), false, nil},
+		{"wrong-signature", "/id/1/200/200.jpg", wrongSignature, false, ErrInvalidSignature},
+	}
+
+	for _, test := range tests {
+		err := Validate(h, test.Target, test.Signature, test.RequireExpiry)
+		if err != test.ExpectedErr {
+			t.Errorf("%s: got error %v, expected %v", test.Name, err, test.ExpectedErr)
+		}
+	}
+}
+
+func mustCreate(t *testing.T, h *hmac.HMAC, target string) string {
+	t.Helper()
+
+	signature, err := h.Create(target)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	return signature
+}
@@ -0,0 +1,89 @@
+// Package singleflight coordinates duplicate concurrent work for the same
+// key, so that when many clients request the exact same render at once,
+// only one of them actually performs it and every waiter receives the same
+// result. It is intended for the image processor's render pipeline, keyed
+// by the same canonical parameter tuple used for the render cache lookup.
+package singleflight
+
+import (
+	"context"
+	"sync"
+)
+
+// call tracks a single in-flight Do call, shared by every waiter for its key.
+type call struct {
+	wg sync.WaitGroup
+
+	result []byte
+	err    error
+
+	cancel  context.CancelFunc
+	waiters int
+}
+
+// Group coordinates calls to Do.
+type Group struct {
+	mtx      sync.Mutex
+	inflight map[string]*call
+}
+
+// New returns a new, empty Group.
+func New() *Group {
+	return &Group{inflight: make(map[string]*call)}
+}
+
+// Do runs fn for key, unless a call for key is already in flight, in which
+// case it waits for that call's result instead of running fn again.
+//
+// fn runs with a context detached from every individual caller's ctx, since
+// canceling it would abort the render for every other waiter too. It's only
+// canceled once every waiter for key has abandoned the call.
+func (g *Group) Do(ctx context.Context, key string, fn func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	g.mtx.Lock()
+	if c, ok := g.inflight[key]; ok {
+		c.waiters++
+		g.mtx.Unlock()
+		return g.wait(ctx, c)
+	}
+
+	renderCtx, cancel := context.WithCancel(context.Background())
+	c := &call{cancel: cancel, waiters: 1}
+	c.wg.Add(1)
+	g.inflight[key] = c
+	g.mtx.Unlock()
+
+	go func() {
+		c.result, c.err = fn(renderCtx)
+
+		g.mtx.Lock()
+		delete(g.inflight, key)
+		g.mtx.Unlock()
+
+		c.wg.Done()
+	}()
+
+	return g.wait(ctx, c)
+}
+
+// wait blocks until c completes or ctx is canceled, whichever comes first.
+func (g *Group) wait(ctx context.Context, c *call) ([]byte, error) {
+	done := make(chan struct{})
+	go func() {
+		c.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return c.result, c.err
+	case <-ctx.Done():
+		g.mtx.Lock()
+		c.waiters--
+		if c.waiters == 0 {
+			c.cancel()
+		}
+		g.mtx.Unlock()
+
+		return nil, ctx.Err()
+	}
+}
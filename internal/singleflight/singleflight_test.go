@@ -0,0 +1,125 @@
+package singleflight
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDoCollapsesConcurrentCallers(t *testing.T) {
+	g := New()
+
+	const n = 50
+	var calls int32
+	ready := make(chan struct{})
+	release := make(chan struct{})
+
+	fn := func(ctx context.Context) ([]byte, error) {
+		atomic.AddInt32(&calls, 1)
+		close(ready)
+		<-release
+		return []byte("result"), nil
+	}
+
+	results := make([][]byte, n)
+	var entered, wg sync.WaitGroup
+	entered.Add(n)
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer wg.Done()
+			entered.Done()
+			result, err := g.Do(context.Background(), "key", fn)
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+			results[i] = result
+		}(i)
+	}
+
+	// Wait for every caller to have joined the in-flight call, then let the
+	// render complete for all of them at once.
+	entered.Wait()
+	<-ready
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("fn invoked %d times, expected 1", got)
+	}
+
+	for i, result := range results {
+		if string(result) != "result" {
+			t.Errorf("waiter %d: got %q, expected %q", i, result, "result")
+		}
+	}
+}
+
+func TestDoDoesNotCollapseDifferentKeys(t *testing.T) {
+	g := New()
+
+	var calls int32
+	fn := func(ctx context.Context) ([]byte, error) {
+		atomic.AddInt32(&calls, 1)
+		return []byte("result"), nil
+	}
+
+	g.Do(context.Background(), "a", fn)
+	g.Do(context.Background(), "b", fn)
+
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("fn invoked %d times, expected 2", got)
+	}
+}
+
+func TestDoCancelingAWaiterDoesNotAbortTheRender(t *testing.T) {
+	g := New()
+
+	renderStarted := make(chan struct{})
+	release := make(chan struct{})
+	var renderCtxErr error
+	fn := func(ctx context.Context) ([]byte, error) {
+		close(renderStarted)
+		<-release
+		renderCtxErr = ctx.Err()
+		return []byte("result"), nil
+	}
+
+	// The first caller starts the render and stays subscribed for its duration.
+	staying := make(chan struct{})
+	go func() {
+		defer close(staying)
+		result, err := g.Do(context.Background(), "key", fn)
+		if err != nil {
+			t.Errorf("staying waiter: unexpected error: %s", err)
+		}
+		if string(result) != "result" {
+			t.Errorf("staying waiter: got %q, expected %q", result, "result")
+		}
+	}()
+	<-renderStarted
+
+	// A second caller joins the same in-flight call, then gives up.
+	leavingCtx, cancel := context.WithCancel(context.Background())
+	leaving := make(chan struct{})
+	go func() {
+		defer close(leaving)
+		if _, err := g.Do(leavingCtx, "key", fn); err != context.Canceled {
+			t.Errorf("leaving waiter: got error %v, expected %v", err, context.Canceled)
+		}
+	}()
+	cancel()
+	<-leaving
+
+	// The render keeps running for the remaining waiter.
+	close(release)
+	<-staying
+
+	if renderCtxErr != nil {
+		t.Errorf("render context was canceled even though a waiter remained: %s", renderCtxErr)
+	}
+}
@@ -0,0 +1,23 @@
+// Package file implements the storage.Provider interface backed by the local filesystem.
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+)
+
+// Provider is a filesystem-backed implementation of storage.Provider.
+type Provider struct {
+	BaseDir string
+}
+
+// New returns a new filesystem-backed provider rooted at baseDir.
+func New(baseDir string) *Provider {
+	return &Provider{BaseDir: baseDir}
+}
+
+// Get returns the contents of the file at path, relative to BaseDir.
+func (p *Provider) Get(ctx context.Context, path string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(p.BaseDir, path))
+}
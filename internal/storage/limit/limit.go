@@ -0,0 +1,62 @@
+// Package limit wraps a storage.Provider with a hard cap on the size of the
+// bytes it returns, so a runaway source image can't be decoded into memory
+// before its caller has a chance to reject it, eventually tripping the
+// server's handler timeout instead of failing fast.
+package limit
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"github.com/DMarby/picsum-photos/internal/storage"
+)
+
+// ErrTooLarge is returned by Provider.Get when the underlying object
+// exceeds MaxBytes.
+var ErrTooLarge = errors.New("asset exceeds the maximum allowed size")
+
+// DefaultMaxBytes is the cap applied when Provider is constructed with a
+// maxBytes of 0.
+const DefaultMaxBytes = 20 << 20 // 20 MiB
+
+// Provider wraps another storage.Provider, rejecting objects larger than
+// MaxBytes before handing them back to a caller for decoding.
+type Provider struct {
+	storage.Provider
+	MaxBytes int64
+
+	skipped atomic.Int64
+}
+
+// New returns a Provider wrapping next, capping returned payloads at
+// maxBytes. A maxBytes of 0 uses DefaultMaxBytes.
+func New(next storage.Provider, maxBytes int64) *Provider {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+
+	return &Provider{Provider: next, MaxBytes: maxBytes}
+}
+
+// Get returns the bytes stored at path, or ErrTooLarge if they exceed
+// MaxBytes.
+func (p *Provider) Get(ctx context.Context, path string) ([]byte, error) {
+	data, err := p.Provider.Get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > p.MaxBytes {
+		p.skipped.Add(1)
+		return nil, ErrTooLarge
+	}
+
+	return data, nil
+}
+
+// Skipped returns the number of Get calls rejected so far for exceeding
+// MaxBytes, for exposing as a Prometheus counter on a metrics endpoint.
+func (p *Provider) Skipped() int64 {
+	return p.skipped.Load()
+}
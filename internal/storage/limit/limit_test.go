@@ -0,0 +1,63 @@
+package limit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DMarby/picsum-photos/internal/storage/limit"
+	mockStorage "github.com/DMarby/picsum-photos/internal/storage/mock"
+)
+
+func TestGetPassesThroughDataWithinLimit(t *testing.T) {
+	provider := limit.New(&mockStorage.Provider{
+		Data: map[string][]byte{"a.jpg": []byte("hello")},
+	}, 5)
+
+	data, err := provider.Get(context.Background(), "a.jpg")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if string(data) != "hello" {
+		t.Errorf("got %#v, expected %#v", string(data), "hello")
+	}
+
+	if provider.Skipped() != 0 {
+		t.Errorf("got %d skipped, expected 0", provider.Skipped())
+	}
+}
+
+func TestGetRejectsDataOverLimit(t *testing.T) {
+	provider := limit.New(&mockStorage.Provider{
+		Data: map[string][]byte{"a.jpg": []byte("hello")},
+	}, 4)
+
+	if _, err := provider.Get(context.Background(), "a.jpg"); !errors.Is(err, limit.ErrTooLarge) {
+		t.Errorf("got error %#v, expected ErrTooLarge", err)
+	}
+
+	if provider.Skipped() != 1 {
+		t.Errorf("got %d skipped, expected 1", provider.Skipped())
+	}
+}
+
+func TestGetPropagatesUnderlyingErrors(t *testing.T) {
+	provider := limit.New(&mockStorage.Provider{}, 5)
+
+	if _, err := provider.Get(context.Background(), "missing.jpg"); err == nil {
+		t.Errorf("expected an error for a missing path")
+	}
+
+	if provider.Skipped() != 0 {
+		t.Errorf("got %d skipped, expected 0", provider.Skipped())
+	}
+}
+
+func TestNewDefaultsZeroMaxBytes(t *testing.T) {
+	provider := limit.New(&mockStorage.Provider{}, 0)
+
+	if provider.MaxBytes != limit.DefaultMaxBytes {
+		t.Errorf("got MaxBytes %d, expected %d", provider.MaxBytes, limit.DefaultMaxBytes)
+	}
+}
@@ -0,0 +1,24 @@
+// Package mock implements a storage.Provider backed by an in-memory map, for testing.
+package mock
+
+import (
+	"context"
+	"errors"
+)
+
+var errNotFound = errors.New("not found")
+
+// Provider is an in-memory implementation of storage.Provider, for testing.
+type Provider struct {
+	Data map[string][]byte
+}
+
+// Get returns the bytes registered for path, or an error if none are.
+func (p *Provider) Get(ctx context.Context, path string) ([]byte, error) {
+	data, ok := p.Data[path]
+	if !ok {
+		return nil, errNotFound
+	}
+
+	return data, nil
+}
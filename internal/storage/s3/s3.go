@@ -0,0 +1,186 @@
+// Package s3 implements the storage.Provider interface backed by an
+// S3-compatible object store via minio-go, so it can serve as the original
+// image source, the processed-image cache, or both.
+package s3
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+)
+
+// Config configures a Provider's connection to an S3-compatible endpoint.
+type Config struct {
+	// Endpoint is the host[:port] of the S3-compatible service, e.g.
+	// s3.amazonaws.com, or a MinIO/Backblaze/Wasabi host.
+	Endpoint string
+	Region   string
+	Bucket   string
+
+	AccessKey string
+	SecretKey string
+
+	// UseSSL controls whether Endpoint is reached over HTTPS.
+	UseSSL bool
+
+	// PathStyle addresses objects as endpoint/bucket/key instead of
+	// bucket.endpoint/key, required by most non-AWS S3-compatible services.
+	PathStyle bool
+
+	// SSE enables SSE-S3 server-side encryption on every object this
+	// Provider writes.
+	SSE bool
+
+	// RequestTimeout bounds every request this Provider makes. It composes
+	// with any deadline already on the context passed in: whichever of the
+	// two expires first applies, same as the server's HandlerTimeout would
+	// for the request that triggered the call.
+	RequestTimeout time.Duration
+}
+
+// Provider is an S3-compatible implementation of storage.Provider, usable as
+// both the source image store and the processed-image cache.
+type Provider struct {
+	client  *minio.Client
+	bucket  string
+	sse     encrypt.ServerSide
+	timeout time.Duration
+}
+
+// New returns a Provider connected to the S3-compatible endpoint in cfg.
+func New(cfg Config) (*Provider, error) {
+	lookup := minio.BucketLookupDNS
+	if cfg.PathStyle {
+		lookup = minio.BucketLookupPath
+	}
+
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure:       cfg.UseSSL,
+		Region:       cfg.Region,
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var sse encrypt.ServerSide
+	if cfg.SSE {
+		sse = encrypt.NewSSE()
+	}
+
+	return &Provider{
+		client:  client,
+		bucket:  cfg.Bucket,
+		sse:     sse,
+		timeout: cfg.RequestTimeout,
+	}, nil
+}
+
+// Get returns the bytes stored at path.
+func (p *Provider) Get(ctx context.Context, path string) ([]byte, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	obj, err := p.client.GetObject(ctx, p.bucket, path, minio.GetObjectOptions{ServerSideEncryption: p.sse})
+	if err != nil {
+		return nil, err
+	}
+	defer obj.Close()
+
+	return io.ReadAll(obj)
+}
+
+// TeeUpload returns a reader yielding the same bytes as src, while
+// concurrently buffering them in memory to upload to path in the
+// background. Use it to warm the cache with a freshly rendered image
+// without making the first requester wait on the write completing: stream
+// the returned reader straight to the client, and the PutObject call
+// happens on its own.
+//
+// The buffering is deliberate: it decouples the client's read rate (and any
+// PutObject slowness, stalling or failure, bounded by Config.RequestTimeout)
+// from one another entirely, so a slow cache write can never stall or abort
+// an otherwise-healthy client download, and a slow or disconnecting client
+// can never stall the cache write past Config.RequestTimeout. Only ctx
+// itself (the caller going away, e.g. the request ending) force-closes the
+// pipe so the background copy doesn't block forever on an abandoned read.
+func (p *Provider) TeeUpload(ctx context.Context, path string, src io.Reader, size int64) io.Reader {
+	pr, pw := io.Pipe()
+
+	go p.bufferAndUpload(ctx, pr, path, size)
+
+	return &teeUploadReader{tee: io.TeeReader(src, pw), pw: pw}
+}
+
+// bufferAndUpload copies pr's bytes into memory, then uploads the buffered
+// copy to path once fully collected. Buffering first - rather than handing
+// PutObject the pipe directly - means the upload's own pace or timeout never
+// feeds back into the client-facing reader on the other end of the pipe.
+func (p *Provider) bufferAndUpload(ctx context.Context, pr *io.PipeReader, path string, size int64) {
+	var buf bytes.Buffer
+	copyDone := make(chan struct{})
+
+	go func() {
+		defer close(copyDone)
+		io.Copy(&buf, pr)
+	}()
+
+	select {
+	case <-copyDone:
+	case <-ctx.Done():
+		pr.CloseWithError(ctx.Err())
+		<-copyDone
+	}
+
+	if int64(buf.Len()) != size {
+		// The client abandoned the read, or src errored, before the full
+		// object was collected; there's nothing complete to cache.
+		return
+	}
+
+	// The object is fully buffered, so the upload no longer has anything to
+	// do with the request that produced it: bound it by RequestTimeout alone
+	// rather than ctx, so it finishes (or gives up) on its own.
+	uploadCtx, cancel := p.withTimeout(context.Background())
+	defer cancel()
+
+	opts := minio.PutObjectOptions{ServerSideEncryption: p.sse}
+	p.client.PutObject(uploadCtx, p.bucket, path, bytes.NewReader(buf.Bytes()), size, opts)
+}
+
+func (p *Provider) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if p.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, p.timeout)
+}
+
+// teeUploadReader closes the pipe feeding the background upload once its
+// source is exhausted, with io.EOF signaling a complete object rather than
+// an aborted one.
+type teeUploadReader struct {
+	tee       io.Reader
+	pw        *io.PipeWriter
+	closeOnce sync.Once
+}
+
+func (t *teeUploadReader) Read(p []byte) (int, error) {
+	n, err := t.tee.Read(p)
+	if err != nil {
+		t.closeOnce.Do(func() {
+			if err == io.EOF {
+				t.pw.Close()
+			} else {
+				t.pw.CloseWithError(err)
+			}
+		})
+	}
+	return n, err
+}
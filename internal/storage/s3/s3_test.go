@@ -0,0 +1,289 @@
+package s3
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3Server is a minimal S3-compatible server: enough of GetObject and
+// PutObject for minio-go's client to round-trip through, so Provider can be
+// tested without a real S3-compatible endpoint.
+func fakeS3Server(t *testing.T) (*httptest.Server, *sync.Map) {
+	t.Helper()
+
+	objects := &sync.Map{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			data, err := decodeAWSChunkedBody(r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			objects.Store(r.URL.Path, data)
+			w.Header().Set("ETag", `"fake"`)
+			w.WriteHeader(http.StatusOK)
+		case http.MethodGet, http.MethodHead:
+			data, ok := objects.Load(r.URL.Path)
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("ETag", `"fake"`)
+			w.Header().Set("Content-Length", strconv.Itoa(len(data.([]byte))))
+			w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+			w.WriteHeader(http.StatusOK)
+			if r.Method == http.MethodGet {
+				w.Write(data.([]byte))
+			}
+		default:
+			http.Error(w, "unsupported method", http.StatusMethodNotAllowed)
+		}
+	}))
+
+	t.Cleanup(server.Close)
+
+	return server, objects
+}
+
+// decodeAWSChunkedBody reads r.Body, decoding the aws-chunked,
+// streaming-signature-v4 framing minio-go uses for unsigned-payload PUTs
+// over plain HTTP (RFC: <hex-size>;chunk-signature=<hex>\r\n<data>\r\n,
+// repeated, terminated by a zero-size chunk) and returns the plain payload.
+// A body that isn't in this format (e.g. a small unchunked PUT) is returned
+// as-is.
+func decodeAWSChunkedBody(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+
+	br := bufio.NewReader(r.Body)
+	var out bytes.Buffer
+
+	for {
+		header, err := br.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(header), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		if size == 0 {
+			return out.Bytes(), nil
+		}
+
+		if _, err := io.CopyN(&out, br, size); err != nil {
+			return nil, err
+		}
+
+		// Trailing CRLF after the chunk's data.
+		if _, err := br.Discard(2); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func newTestProvider(t *testing.T) *Provider {
+	t.Helper()
+
+	server, _ := fakeS3Server(t)
+
+	p, err := New(Config{
+		Endpoint:  server.Listener.Addr().String(),
+		Region:    "us-east-1",
+		Bucket:    "test-bucket",
+		AccessKey: "access",
+		SecretKey: "secret",
+		PathStyle: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	return p
+}
+
+func TestGetRoundTrip(t *testing.T) {
+	server, objects := fakeS3Server(t)
+	objects.Store("/test-bucket/1.jpg", []byte("hello world"))
+
+	p, err := New(Config{
+		Endpoint:  server.Listener.Addr().String(),
+		Region:    "us-east-1",
+		Bucket:    "test-bucket",
+		AccessKey: "access",
+		SecretKey: "secret",
+		PathStyle: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	data, err := p.Get(context.Background(), "1.jpg")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if string(data) != "hello world" {
+		t.Errorf("got %#v, expected the stored bytes", string(data))
+	}
+}
+
+func TestGetNotFound(t *testing.T) {
+	p := newTestProvider(t)
+
+	if _, err := p.Get(context.Background(), "missing.jpg"); err == nil {
+		t.Errorf("expected an error for a missing object")
+	}
+}
+
+func TestTeeUploadStreamsToCallerImmediately(t *testing.T) {
+	p := newTestProvider(t)
+
+	src := bytes.NewReader([]byte("hello world"))
+	reader := p.TeeUpload(context.Background(), "1.jpg", src, int64(src.Len()))
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("unexpected error reading the tee: %s", err)
+	}
+
+	if string(data) != "hello world" {
+		t.Errorf("got %#v, expected the source bytes", string(data))
+	}
+
+	// The background PutObject may still be in flight right after Read
+	// returns io.EOF, so poll briefly for it to land rather than racing it.
+	deadline := time.Now().Add(time.Second)
+	for {
+		uploaded, err := p.Get(context.Background(), "1.jpg")
+		if err == nil {
+			if string(uploaded) != "hello world" {
+				t.Errorf("got %#v, expected the background upload to match the source", string(uploaded))
+			}
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("background upload never completed: %s", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestTeeUploadReaderPropagatesSourceError(t *testing.T) {
+	p := newTestProvider(t)
+
+	wantErr := errors.New("source exploded")
+	src := &erroringReader{err: wantErr}
+
+	reader := p.TeeUpload(context.Background(), "1.jpg", src, 1)
+
+	if _, err := io.ReadAll(reader); err != wantErr {
+		t.Errorf("got error %v, expected %v", err, wantErr)
+	}
+}
+
+type erroringReader struct {
+	err error
+}
+
+func (r *erroringReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
+
+// TestTeeUploadAbandonedReaderUnblocksOnContextCancel covers the case where
+// the caller stops reading before EOF (e.g. a client disconnecting
+// mid-download): the background PutObject must unblock once ctx is
+// canceled, rather than leaking a goroutine blocked on the pipe forever.
+func TestTeeUploadAbandonedReaderUnblocksOnContextCancel(t *testing.T) {
+	p := newTestProvider(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	src := bytes.NewReader(make([]byte, 1<<20)) // 1MB, far more than we'll read
+	reader := p.TeeUpload(ctx, "leak.jpg", src, int64(src.Len()))
+
+	buf := make([]byte, 10)
+	if _, err := reader.Read(buf); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	// Abandon the reader here without reading to EOF, then cancel its context,
+	// simulating a client disconnect.
+
+	before := runtime.NumGoroutine()
+	cancel()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		runtime.GC()
+		if after := runtime.NumGoroutine(); after < before {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("background upload goroutine never unblocked after ctx cancellation")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestTeeUploadSurvivesSlowCacheWrite covers the opposite failure mode from
+// the abandoned-reader case above: the client is still actively reading, but
+// the cache write is slow enough to blow past RequestTimeout. That must only
+// fail the cache write - the client's read of the source bytes has to
+// complete untouched.
+func TestTeeUploadSurvivesSlowCacheWrite(t *testing.T) {
+	block := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			<-block
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	defer close(block)
+
+	p, err := New(Config{
+		Endpoint:       server.Listener.Addr().String(),
+		Region:         "us-east-1",
+		Bucket:         "test-bucket",
+		AccessKey:      "access",
+		SecretKey:      "secret",
+		PathStyle:      true,
+		RequestTimeout: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := []byte("hello world, the cache write is stuck")
+	src := bytes.NewReader(want)
+	reader := p.TeeUpload(context.Background(), "1.jpg", src, int64(len(want)))
+
+	got, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("client read failed because of an unrelated slow cache write: %s", err)
+	}
+
+	if string(got) != string(want) {
+		t.Errorf("got %#v, expected the source bytes", string(got))
+	}
+}
@@ -0,0 +1,10 @@
+// Package storage defines the interface used to fetch the original image bytes.
+package storage
+
+import "context"
+
+// Provider is the interface implemented by the different image storage backends.
+type Provider interface {
+	// Get returns the raw bytes stored at path.
+	Get(ctx context.Context, path string) ([]byte, error)
+}
@@ -0,0 +1,212 @@
+// Package timeout implements an adaptive per-route HTTP handler timeout, in
+// place of a single fixed value. Image-processing requests vary wildly in
+// cost with the requested size, blur and format, so a Tracker watches the
+// last few handler durations per route and grows or decays that route's
+// timeout between configured bounds to track them.
+package timeout
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// historySize is the number of recent handler durations kept per route.
+const historySize = 16
+
+// Config bounds and tunes a Tracker's feedback loop.
+type Config struct {
+	// Min and Max bound the timeout a route can grow or decay to.
+	Min time.Duration
+	Max time.Duration
+
+	// Initial is the timeout a route starts at, before any requests have
+	// been observed.
+	Initial time.Duration
+
+	// GrowthFactor is the multiplier applied to a route's timeout when it
+	// grows.
+	GrowthFactor float64
+
+	// GrowThreshold and DecayThreshold are fractions, in [0, 1], of recent
+	// requests that exceeded the current timeout. Above GrowThreshold the
+	// timeout grows; below DecayThreshold it decays toward the observed
+	// 95th percentile duration. Between the two it's left alone.
+	GrowThreshold  float64
+	DecayThreshold float64
+}
+
+// DefaultConfig matches the previously hardcoded 45s handler timeout, and
+// lets it range between 5s and 90s as the observed workload demands.
+var DefaultConfig = Config{
+	Min:            5 * time.Second,
+	Max:            90 * time.Second,
+	Initial:        45 * time.Second,
+	GrowthFactor:   1.5,
+	GrowThreshold:  0.33,
+	DecayThreshold: 0.10,
+}
+
+// RouteMetrics is a point-in-time snapshot of a route's adaptive timeout state.
+type RouteMetrics struct {
+	Route        string
+	Current      time.Duration
+	GrowthEvents int64
+}
+
+// route holds the adaptive state for a single named route.
+type route struct {
+	current      atomic.Int64 // nanoseconds, read/written under atomic load/store
+	growthEvents atomic.Int64
+
+	mtx       sync.Mutex
+	durations [historySize]time.Duration
+	exceeded  [historySize]bool
+	count     int
+	next      int
+}
+
+// Tracker tracks an adaptive timeout per route, keyed by route name.
+type Tracker struct {
+	cfg Config
+
+	mtx    sync.Mutex
+	routes map[string]*route
+}
+
+// New returns a Tracker that grows and decays route timeouts per cfg.
+func New(cfg Config) *Tracker {
+	return &Tracker{cfg: cfg, routes: make(map[string]*route)}
+}
+
+// Middleware wraps next in http.TimeoutHandler, using route's current
+// adaptive timeout loaded atomically on every request, and records the
+// handler's actual duration to feed back into that timeout.
+func (t *Tracker) Middleware(route string, timeoutMessage string) func(http.Handler) http.Handler {
+	r := t.routeFor(route)
+
+	return func(next http.Handler) http.Handler {
+		instrumented := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			start := time.Now()
+			next.ServeHTTP(w, req)
+			t.record(r, time.Since(start))
+		})
+
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			current := time.Duration(r.current.Load())
+			http.TimeoutHandler(instrumented, current, timeoutMessage).ServeHTTP(w, req)
+		})
+	}
+}
+
+// Metrics returns a snapshot of every route's current timeout and growth
+// event count, for exposing on a health/metrics endpoint.
+func (t *Tracker) Metrics() []RouteMetrics {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	metrics := make([]RouteMetrics, 0, len(t.routes))
+	for name, r := range t.routes {
+		metrics = append(metrics, RouteMetrics{
+			Route:        name,
+			Current:      time.Duration(r.current.Load()),
+			GrowthEvents: r.growthEvents.Load(),
+		})
+	}
+
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].Route < metrics[j].Route })
+
+	return metrics
+}
+
+func (t *Tracker) routeFor(name string) *route {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	r, ok := t.routes[name]
+	if !ok {
+		r = &route{}
+		r.current.Store(int64(t.cfg.Initial))
+		t.routes[name] = r
+	}
+
+	return r
+}
+
+// record folds d into r's history and grows or decays r's timeout if the
+// fraction of recent requests that exceeded it crossed a threshold.
+func (t *Tracker) record(r *route, d time.Duration) {
+	current := time.Duration(r.current.Load())
+
+	r.mtx.Lock()
+	r.durations[r.next] = d
+	r.exceeded[r.next] = d >= current
+	r.next = (r.next + 1) % historySize
+	if r.count < historySize {
+		r.count++
+	}
+
+	n := r.count
+	samples := make([]time.Duration, n)
+	copy(samples, r.durations[:n])
+
+	exceededCount := 0
+	for i := 0; i < n; i++ {
+		if r.exceeded[i] {
+			exceededCount++
+		}
+	}
+	r.mtx.Unlock()
+
+	if n == 0 {
+		return
+	}
+
+	fraction := float64(exceededCount) / float64(n)
+
+	switch {
+	case fraction > t.cfg.GrowThreshold:
+		grown := time.Duration(float64(current) * t.cfg.GrowthFactor)
+		if grown > t.cfg.Max {
+			grown = t.cfg.Max
+		}
+		if grown > current {
+			r.current.Store(int64(grown))
+			r.growthEvents.Add(1)
+		}
+	case fraction < t.cfg.DecayThreshold:
+		decayed := clamp(percentile(samples, 0.95), t.cfg.Min, t.cfg.Max)
+		if decayed < current {
+			r.current.Store(int64(decayed))
+		}
+	}
+}
+
+// percentile returns the value at p (in [0, 1]) of samples, nearest-rank.
+func percentile(samples []time.Duration, p float64) time.Duration {
+	sorted := make([]time.Duration, len(samples))
+	copy(sorted, samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	index := int(p*float64(len(sorted))) - 1
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(sorted) {
+		index = len(sorted) - 1
+	}
+
+	return sorted[index]
+}
+
+func clamp(d, lo, hi time.Duration) time.Duration {
+	if d < lo {
+		return lo
+	}
+	if d > hi {
+		return hi
+	}
+	return d
+}
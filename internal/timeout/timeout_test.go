@@ -0,0 +1,114 @@
+package timeout
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func testConfig() Config {
+	return Config{
+		Min:            5 * time.Millisecond,
+		Max:            200 * time.Millisecond,
+		Initial:        20 * time.Millisecond,
+		GrowthFactor:   1.5,
+		GrowThreshold:  0.33,
+		DecayThreshold: 0.10,
+	}
+}
+
+func sleepHandler(d time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(d)
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func serve(t *testing.T, tracker *Tracker, route string, handler http.Handler) {
+	t.Helper()
+
+	wrapped := tracker.Middleware(route, "timed out")(handler)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	wrapped.ServeHTTP(w, req)
+}
+
+func TestMiddlewareServesFastRequestsNormally(t *testing.T) {
+	tracker := New(testConfig())
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	tracker.Middleware("route", "timed out")(sleepHandler(0)).ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("got status %d, expected %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestMiddlewareGrowsTimeoutAfterRepeatedlyExceedingIt(t *testing.T) {
+	tracker := New(testConfig())
+
+	// historySize slow requests, each exceeding the 20ms initial timeout,
+	// should push the fraction-exceeded well past GrowThreshold. The handler
+	// keeps running past http.TimeoutHandler's own cutoff, so give its
+	// recording goroutine a moment to catch up before asserting.
+	for i := 0; i < historySize; i++ {
+		serve(t, tracker, "slow-route", sleepHandler(30*time.Millisecond))
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	metrics := routeMetrics(t, tracker, "slow-route")
+	if metrics.Current <= 20*time.Millisecond {
+		t.Errorf("timeout did not grow, got %s", metrics.Current)
+	}
+	if metrics.GrowthEvents == 0 {
+		t.Errorf("expected at least one growth event")
+	}
+}
+
+func TestMiddlewareDecaysTimeoutTowardFastRequests(t *testing.T) {
+	cfg := testConfig()
+	cfg.Initial = 100 * time.Millisecond
+	tracker := New(cfg)
+
+	for i := 0; i < historySize; i++ {
+		serve(t, tracker, "fast-route", sleepHandler(time.Millisecond))
+	}
+
+	metrics := routeMetrics(t, tracker, "fast-route")
+	if metrics.Current >= 100*time.Millisecond {
+		t.Errorf("timeout did not decay, got %s", metrics.Current)
+	}
+	if metrics.Current < cfg.Min {
+		t.Errorf("timeout decayed below Min, got %s", metrics.Current)
+	}
+}
+
+func TestTimeoutNeverExceedsMax(t *testing.T) {
+	cfg := testConfig()
+	cfg.Max = 25 * time.Millisecond
+	tracker := New(cfg)
+
+	for i := 0; i < historySize*3; i++ {
+		serve(t, tracker, "route", sleepHandler(50*time.Millisecond))
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	if metrics := routeMetrics(t, tracker, "route"); metrics.Current > cfg.Max {
+		t.Errorf("timeout exceeded Max, got %s", metrics.Current)
+	}
+}
+
+func routeMetrics(t *testing.T, tracker *Tracker, route string) RouteMetrics {
+	t.Helper()
+
+	for _, m := range tracker.Metrics() {
+		if m.Route == route {
+			return m
+		}
+	}
+
+	t.Fatalf("no metrics recorded for route %q", route)
+	return RouteMetrics{}
+}
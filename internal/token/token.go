@@ -0,0 +1,79 @@
+// Package token implements short-lived, HMAC-signed bearer tokens used to scope
+// and rate-limit programmatic access to the metadata endpoints.
+package token
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/DMarby/picsum-photos/internal/hmac"
+)
+
+// Errors returned by Parse.
+var (
+	ErrMalformed        = errors.New("malformed token")
+	ErrInvalidSignature = errors.New("invalid token signature")
+	ErrExpired          = errors.New("token expired")
+)
+
+// Claims describes a token's expiry and optional scope.
+type Claims struct {
+	ExpiresAt int64 `json:"exp"`
+
+	// AllowedAuthor, if set, restricts /v2/list to only that author.
+	AllowedAuthor string `json:"author,omitempty"`
+}
+
+// Issue returns a new token granting claims, expiring after ttl, signed with h.
+func Issue(h *hmac.HMAC, claims Claims, ttl time.Duration) (string, error) {
+	claims.ExpiresAt = time.Now().Add(ttl).Unix()
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	encodedPayload := base64.RawURLEncoding.EncodeToString(payload)
+
+	signature, err := h.Create(encodedPayload)
+	if err != nil {
+		return "", err
+	}
+
+	return encodedPayload + "." + signature, nil
+}
+
+// Parse validates the signature and expiry of a token and returns its claims.
+func Parse(h *hmac.HMAC, token string) (Claims, error) {
+	encodedPayload, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return Claims{}, ErrMalformed
+	}
+
+	valid, err := h.Validate(encodedPayload, signature)
+	if err != nil {
+		return Claims{}, err
+	}
+	if !valid {
+		return Claims{}, ErrInvalidSignature
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return Claims{}, ErrMalformed
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return Claims{}, ErrMalformed
+	}
+
+	if time.Now().Unix() > claims.ExpiresAt {
+		return Claims{}, ErrExpired
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,31 @@
+// Package tracing sets up OpenTelemetry tracing for the application.
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Tracer holds the configuration and state for a service's tracer.
+type Tracer struct {
+	ServiceName    string
+	Log            *zap.Logger
+	TracerProvider trace.TracerProvider
+	ShutdownFunc   func(context.Context) error
+}
+
+// Tracer returns a trace.Tracer for the configured service.
+func (t *Tracer) Tracer() trace.Tracer {
+	return t.TracerProvider.Tracer(t.ServiceName)
+}
+
+// Shutdown flushes and stops the underlying tracer provider.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t.ShutdownFunc == nil {
+		return nil
+	}
+
+	return t.ShutdownFunc(ctx)
+}